@@ -0,0 +1,103 @@
+package refbook
+
+// WithInactive informs that JSON, Contains and Search include inactive
+// items. Name, IsExist and IDByName resolve inactive items regardless
+// of the option.
+func WithInactive() func(o *Option) {
+	return func(o *Option) {
+		o.withInactive = true
+	}
+}
+
+// jsonItems returns items written to JSON.
+func (b *BookOf[K, V]) jsonItems(items []ItemOf[K, V]) []ItemOf[K, V] {
+	if b.withInactive {
+		return items
+	}
+	return activeItems(items)
+}
+
+// activeItems returns items without inactive ones. Items are returned
+// as they are if all of them are active.
+func activeItems[K comparable, V any](items []ItemOf[K, V]) []ItemOf[K, V] {
+	for i := range items {
+		if !items[i].Inactive {
+			continue
+		}
+		res := append(make([]ItemOf[K, V], 0, len(items)-1), items[:i]...)
+		for _, item := range items[i+1:] {
+			if !item.Inactive {
+				res = append(res, item)
+			}
+		}
+		return res
+	}
+	return items
+}
+
+// ActiveItems returns copy of active items in order given by option
+// WithOrder.
+func (b *BookOf[K, V]) ActiveItems() []ItemOf[K, V] {
+	items := b.snapshot().jsonInput.Items
+	if res := activeItems(items); len(res) != len(items) {
+		return res
+	}
+	return append([]ItemOf[K, V](nil), items...)
+}
+
+// ActiveItems returns copy of active items in language lang.
+func (b *FlexBook) ActiveItems(lang string) []Item {
+	fb := b.books.Load()
+	return fb.book[fb.languageIndex(lang, &b.langs)].ActiveItems()
+}
+
+// SetActive activates or retires the item. Retired item is still
+// resolved by Name and IsExist. Does nothing if an item does not exist.
+func (b *BookOf[K, V]) SetActive(id K, isActive bool) {
+	if !b.IsExist(id) {
+		return
+	}
+
+	b.mutate(func(s *bookSnapshot[K, V]) bool {
+		e, ok := b.setActive(s, id, isActive)
+		if ok {
+			b.events.push(e)
+		}
+		return ok
+	})
+}
+
+// setActive sets activity of the item of s. Returns event EventActivated
+// or EventRetired, false if nothing changed.
+func (b *BookOf[K, V]) setActive(s *bookSnapshot[K, V], id K, isActive bool) (EventOf[K], bool) {
+	i := s.position(id)
+	if i == -1 || s.jsonInput.Items[i].Inactive == !isActive {
+		return EventOf[K]{}, false
+	}
+	item := s.jsonInput.Items[i]
+	item.Inactive = !isActive
+	e, ok := b.set(s, item, s.uItems[i].fallback)
+	e.Type = EventRetired
+	if isActive {
+		e.Type = EventActivated
+	}
+	return e, ok
+}
+
+// SetActive activates or retires the item in all languages.
+// Does nothing if an item does not exist.
+func (b *FlexBook) SetActive(id int, isActive bool) {
+	if !b.IsExist(id) {
+		return
+	}
+
+	_ = b.mutate(func(fb *flexBooks) error {
+		for i := range fb.book {
+			if e, ok := fb.book[i].setActive(fb.book[i].snapshot(), id, isActive); ok {
+				e.Lang = fb.bi[i]
+				b.events.push(e)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,136 @@
+package refbook
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestBook_Inactive(t *testing.T) {
+
+	src := []byte(`[{"id":1,"name":"Cash"},{"id":2,"name":"Cheque","is_active":false},{"id":3,"name":"Card","is_active":true}]`)
+
+	b := NewConcurrentBook()
+	if err := b.Parse(src); err != nil {
+		t.Fatal(err)
+	}
+
+	if name := b.Name(0, 2); name != "Cheque" {
+		t.Errorf("expected Cheque, got %s", name)
+	}
+	if !b.IsExist(2) {
+		t.Error("expected inactive item exists")
+	}
+
+	expected := `{"items":[{"id":1,"name":"Cash"},{"id":3,"name":"Card"}],"hash":"`
+	if res := string(b.JSON()); len(res) < len(expected) || res[:len(expected)] != expected {
+		t.Errorf("unexpected JSON %s", res)
+	}
+
+	var ids []int
+	b.Contains("ch", &ids)
+	if len(ids) != 0 {
+		t.Errorf("expected no items, got %v", ids)
+	}
+	if res := b.Search(0, "cheque", 0); len(res) != 0 {
+		t.Errorf("expected no items, got %v", res)
+	}
+
+	if res, expected := itemIDs(b.ActiveItems()), []int{1, 3}; !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected %v, got %v", expected, res)
+	}
+	if res, expected := itemIDs(b.Items()), []int{1, 2, 3}; !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected %v, got %v", expected, res)
+	}
+
+	// renamed item stays inactive.
+	b.Set(2, "Check")
+	b.SetActive(1, false)
+	b.SetActive(4, false)
+	if res, expected := itemIDs(b.ActiveItems()), []int{3}; !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected %v, got %v", expected, res)
+	}
+
+	b.SetActive(2, true)
+	if res, expected := itemIDs(b.ActiveItems()), []int{2, 3}; !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected %v, got %v", expected, res)
+	}
+}
+
+func TestBook_WithInactive(t *testing.T) {
+
+	src := []byte(`[{"id":1,"name":"Cash"},{"id":2,"name":"Cheque","is_active":false}]`)
+
+	b := NewBook(WithInactive())
+	if err := b.Parse(src); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"items":[{"id":1,"name":"Cash"},{"id":2,"name":"Cheque","is_active":false}],"hash":"`
+	if res := string(b.JSON()); len(res) < len(expected) || res[:len(expected)] != expected {
+		t.Errorf("unexpected JSON %s", res)
+	}
+
+	var buf bytes.Buffer
+	if _, err := b.WriteJSON(&buf, WithRowFields("id", "name"), WithEnvelope("items", "hash")); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != string(b.JSON()) {
+		t.Errorf("expected %s, got %s", b.JSON(), buf.String())
+	}
+
+	var ids []int
+	b.Contains("ch", &ids)
+	if !reflect.DeepEqual(ids, []int{2}) {
+		t.Errorf("expected [2], got %v", ids)
+	}
+
+	// flag is a part of the hash.
+	a := NewBook()
+	if err := a.Parse([]byte(`[{"id":1,"name":"Cash"},{"id":2,"name":"Cheque"}]`)); err != nil {
+		t.Fatal(err)
+	}
+	if a.Hash() == b.Hash() {
+		t.Error("expected different hashes")
+	}
+}
+
+func TestFlexBook_Inactive(t *testing.T) {
+
+	src := []byte(`[{"id":1,"name":{"en":"Cash","ru":"Наличные"}},{"id":2,"name":{"en":"Cheque","ru":"Чек"},"is_active":false}]`)
+
+	b := NewFlexBook(WithThreadSafe(), WithDefaultLang("en"))
+	if err := b.Parse(src); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Optimize(); err != nil {
+		t.Fatal(err)
+	}
+
+	if name := b.Name(ToLangCode("ru"), 2); name != "Чек" {
+		t.Errorf("expected Чек, got %s", name)
+	}
+	if res, expected := itemIDs(b.ActiveItems("ru")), []int{1}; !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected %v, got %v", expected, res)
+	}
+
+	var buf bytes.Buffer
+	if _, err := b.WriteJSON(&buf, "ru", WithEnvelope("data", "hash")); err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"data":[{"id":1,"name":"Наличные"}],"hash":"`; !bytes.HasPrefix(buf.Bytes(), []byte(expected)) {
+		t.Errorf("unexpected JSON %s", buf.String())
+	}
+
+	b.SetActive(2, true)
+	b.SetActive(1, false)
+	for _, lang := range []string{"en", "ru"} {
+		if res, expected := itemIDs(b.ActiveItems(lang)), []int{2}; !reflect.DeepEqual(res, expected) {
+			t.Errorf("%s: expected %v, got %v", lang, expected, res)
+		}
+	}
+
+	if res := b.Search("cash"); len(res) != 0 {
+		t.Errorf("expected no items, got %v", res)
+	}
+}
@@ -0,0 +1,68 @@
+package refbook
+
+import "strings"
+
+// newNameItem returns name forms of the item and of its aliases.
+func newNameItem[K comparable](id K, name string, aliases []string, inactive bool) nameItem[K] {
+	ni := nameItem[K]{ID: id, Name: name, UName: strings.ToUpper(name), inactive: inactive}
+	ni.FName, ni.fOffs = foldOffsets(name)
+
+	for _, a := range aliases {
+		if a == "" || a == name {
+			continue
+		}
+		ai := nameItem[K]{ID: id, Name: a, UName: strings.ToUpper(a)}
+		ai.FName, ai.fOffs = foldOffsets(a)
+		ni.aliases = append(ni.aliases, ai)
+	}
+	return ni
+}
+
+// index adds the item to indexes by name and by aliases. Names taken
+// from another language are not indexed.
+func (s *bookSnapshot[K, V]) index(ni nameItem[K]) {
+	if ni.fallback {
+		return
+	}
+	for _, x := range append([]nameItem[K]{ni}, ni.aliases...) {
+		s.byName[x.Name] = appendID(s.byName[x.Name], x.ID)
+		s.byUName[x.UName] = appendID(s.byUName[x.UName], x.ID)
+		s.byFName[x.FName] = appendID(s.byFName[x.FName], x.ID)
+	}
+}
+
+// unindex removes the item from indexes by name and by aliases.
+func (s *bookSnapshot[K, V]) unindex(ni nameItem[K]) {
+	if ni.fallback {
+		return
+	}
+	for _, x := range append([]nameItem[K]{ni}, ni.aliases...) {
+		removeID(s.byName, x.Name, x.ID)
+		removeID(s.byUName, x.UName, x.ID)
+		removeID(s.byFName, x.FName, x.ID)
+	}
+}
+
+// contains returns true if the name or any alias of the item contains s
+// compared according to m.
+func (ni *nameItem[K]) contains(s string, m NameMatch) bool {
+	if strings.Contains(ni.form(m), s) {
+		return true
+	}
+	for i := range ni.aliases {
+		if strings.Contains(ni.aliases[i].form(m), s) {
+			return true
+		}
+	}
+	return false
+}
+
+// aliases returns aliases of the item in language lc.
+func (item *MultiLangItem) aliases(lc LangCode) []string {
+	for lang, as := range item.Aliases {
+		if ToLangCode(lang) == lc {
+			return as
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package refbook
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestBook_Aliases(t *testing.T) {
+
+	src := []byte(`[
+		{"id":1,"name":"United States","aliases":["USA","America"]},
+		{"id":2,"name":"United Kingdom","aliases":["UK","Great Britain"]},
+		{"id":3,"name":"Germany"}
+	]`)
+
+	b := NewConcurrentBook()
+	if err := b.Parse(src); err != nil {
+		t.Fatal(err)
+	}
+
+	if name := b.Name(0, 1); name != "United States" {
+		t.Errorf("expected United States, got %s", name)
+	}
+
+	var ids []int
+	b.Contains("americ", &ids)
+	if !reflect.DeepEqual(ids, []int{1}) {
+		t.Errorf("expected [1], got %v", ids)
+	}
+	b.Contains("unit", &ids)
+	if !reflect.DeepEqual(ids, []int{1, 2}) {
+		t.Errorf("expected [1 2], got %v", ids)
+	}
+
+	if id, err := b.IDByName(0, "usa", MatchCaseInsensitive); err != nil || id != 1 {
+		t.Errorf("expected 1, got %d %v", id, err)
+	}
+	if id, err := b.IDByName(0, "United Kingdom", MatchExact); err != nil || id != 2 {
+		t.Errorf("expected 2, got %d %v", id, err)
+	}
+
+	res := b.Search(0, "britain", 0)
+	if len(res) != 1 || res[0].ID != 2 || res[0].Name != "United Kingdom" || res[0].Alias != "Great Britain" {
+		t.Fatalf("unexpected result %v", res)
+	}
+	if m := res[0].Alias[res[0].Start:res[0].End]; m != "Britain" {
+		t.Errorf("expected Britain, got %s", m)
+	}
+
+	// name wins over alias of the same score.
+	res = b.Search(0, "germany", 0)
+	if len(res) != 1 || res[0].Alias != "" {
+		t.Errorf("unexpected result %v", res)
+	}
+
+	// replaced aliases are not found anymore.
+	b.Optimize()
+	h := b.Hash()
+	if err := b.Replace([]Item{{ID: 1, Name: "United States", Aliases: []string{"US"}}}); err != nil {
+		t.Fatal(err)
+	}
+	b.Optimize()
+	if _, err := b.IDByName(0, "USA", MatchExact); err != ErrNameNotFound {
+		t.Error("expected USA is not found")
+	}
+	if b.Hash() == h {
+		t.Error("expected hash is changed")
+	}
+}
+
+func TestBook_AliasesJSON(t *testing.T) {
+
+	b := NewBook()
+	if err := b.Parse([]byte(`[{"id":1,"name":"United States","aliases":["USA"]},{"id":2,"name":"Germany"}]`)); err != nil {
+		t.Fatal(err)
+	}
+	b.Optimize()
+
+	expected := `{"items":[{"id":1,"name":"United States","aliases":["USA"]},{"id":2,"name":"Germany"}],"hash":"`
+	if res := string(b.JSON()); len(res) < len(expected) || res[:len(expected)] != expected {
+		t.Errorf("unexpected JSON %s", res)
+	}
+
+	var buf bytes.Buffer
+	if _, err := b.WriteJSON(&buf, WithRowFields("id", "name"), WithEnvelope("items", "hash")); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != string(b.JSON()) {
+		t.Errorf("expected %s, got %s", b.JSON(), buf.String())
+	}
+}
+
+func TestFlexBook_Aliases(t *testing.T) {
+
+	src := []byte(`[
+		{"id":1,"name":{"en":"United States","ru":"США"},"aliases":{"en":["USA","America"],"ru":["Америка"]}},
+		{"id":2,"name":{"en":"Germany","ru":"Германия"}}
+	]`)
+
+	b := NewFlexBook()
+	if err := b.Parse(src); err != nil {
+		t.Fatal(err)
+	}
+
+	en, ru := ToLangCode("en"), ToLangCode("ru")
+	if name := b.Name(ru, 1); name != "США" {
+		t.Errorf("expected США, got %s", name)
+	}
+	if id, err := b.IDByName(ru, "америка", MatchCaseInsensitive); err != nil || id != 1 {
+		t.Errorf("expected 1, got %d %v", id, err)
+	}
+	if _, err := b.IDByName(ru, "USA", MatchExact); err != ErrNameNotFound {
+		t.Error("expected english alias is not found in russian")
+	}
+	if id, err := b.IDByName(en, "America", MatchExact); err != nil || id != 1 {
+		t.Errorf("expected 1, got %d %v", id, err)
+	}
+
+	res := b.Search("amerika")
+	if len(res) != 1 || res[0].ID != 1 {
+		t.Fatalf("unexpected result %v", res)
+	}
+}
+
+func TestBook_LoadFromSQLAliases(t *testing.T) {
+
+	db := openFakeDB(t)
+	defer db.Close()
+
+	b := NewFlexBook()
+	if err := b.LoadFromSQL(context.Background(), db, "countries", WithAliasesColumn("aliases")); err != nil {
+		t.Fatal(err)
+	}
+	if id, err := b.IDByName(ToLangCode("ru"), "Америка", MatchExact); err != nil || id != 1 {
+		t.Errorf("expected 1, got %d %v", id, err)
+	}
+
+	bk := NewBook()
+	if err := bk.LoadFromSQL(context.Background(), db, "countries", WithAliasesColumn("aliases")); err != nil {
+		t.Fatal(err)
+	}
+	if id, err := bk.IDByName(0, "USA", MatchExact); err != nil || id != 1 {
+		t.Errorf("expected 1, got %d %v", id, err)
+	}
+	if name := bk.Name(0, 1); name != "United States" {
+		t.Errorf("expected United States, got %s", name)
+	}
+}
@@ -0,0 +1,416 @@
+package refbook
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mutexBook replicates read path of the Book guarded by RWMutex.
+// It's used as a baseline in benchmarks.
+type mutexBook struct {
+	mux sync.RWMutex
+	m   map[int]string
+}
+
+func (b *mutexBook) Name(id int) string {
+	b.mux.RLock()
+	res, ok := b.m[id]
+	b.mux.RUnlock()
+	if ok {
+		return res
+	}
+	return NotFoundName
+}
+
+func (b *mutexBook) Set(id int, name string) {
+	b.mux.Lock()
+	b.m[id] = name
+	b.mux.Unlock()
+}
+
+const benchBookSize = 100
+
+func newBenchBooks() (*Book, *mutexBook) {
+	b := NewConcurrentBook()
+	mb := &mutexBook{m: make(map[int]string)}
+	items := make([]Item, benchBookSize)
+	for i := range items {
+		items[i] = Item{ID: i, Name: "name " + strconv.Itoa(i)}
+		mb.m[i] = items[i].Name
+	}
+	if err := b.load(items); err != nil {
+		panic(err)
+	}
+	return b, mb
+}
+
+func BenchmarkBook_Name(b *testing.B) {
+
+	sb, mb := newBenchBooks()
+
+	b.Run("snapshot", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				_ = sb.Name(0, i%benchBookSize)
+				i++
+			}
+		})
+	})
+
+	b.Run("mutex", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				_ = mb.Name(i % benchBookSize)
+				i++
+			}
+		})
+	})
+}
+
+// BenchmarkBook_NameWithWriter measures readers while a single writer
+// modifies the book.
+func BenchmarkBook_NameWithWriter(b *testing.B) {
+
+	sb, mb := newBenchBooks()
+
+	run := func(b *testing.B, read func(id int), write func(id int, name string)) {
+		stop := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				write(i%benchBookSize, strconv.Itoa(i))
+			}
+		}()
+
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				read(i % benchBookSize)
+				i++
+			}
+		})
+		b.StopTimer()
+		close(stop)
+		<-done
+	}
+
+	b.Run("snapshot", func(b *testing.B) {
+		run(b, func(id int) { _ = sb.Name(0, id) }, sb.Set)
+	})
+
+	b.Run("mutex", func(b *testing.B) {
+		run(b, func(id int) { _ = mb.Name(id) }, mb.Set)
+	})
+}
+
+func TestBook_ConcurrentSnapshot(t *testing.T) {
+
+	b := NewConcurrentBook()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			b.Set(i, strconv.Itoa(i))
+			if i%100 == 0 {
+				if err := b.Optimize(); err != nil {
+					t.Error(err)
+				}
+			}
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		var ids []int
+		for i := 0; i < 1000; i++ {
+			s := b.snapshot()
+			if len(s.m) != len(s.jsonInput.Items) || len(s.m) != len(s.uItems) {
+				t.Errorf("inconsistent snapshot: %d, %d, %d", len(s.m), len(s.jsonInput.Items), len(s.uItems))
+				return
+			}
+			b.Contains("1", &ids)
+			_ = b.JSON()
+		}
+	}()
+	wg.Wait()
+
+	if b.Len() != 1000 {
+		t.Errorf("expected 1000 items, got %d", b.Len())
+	}
+
+	old := b.snapshot()
+	b.Set(1, "one")
+	if old.m[1] != "1" {
+		t.Error("published snapshot has been modified")
+	}
+	if b.Name(0, 1) != "one" {
+		t.Errorf("expected one, got %s", b.Name(0, 1))
+	}
+}
+
+func TestBook_Delete(t *testing.T) {
+
+	for _, b := range []*Book{NewBook(), NewConcurrentBook()} {
+		if err := b.Parse([]byte(`[{"id":1,"name":"A"},{"id":2,"name":"B"},{"id":3,"name":"C"}]`)); err != nil {
+			t.Fatal(err)
+		}
+
+		b.Delete(2)
+		b.Delete(4)
+
+		if b.IsExist(2) || b.Len() != 2 {
+			t.Errorf("item 2 not deleted, len %d", b.Len())
+		}
+		if b.Hash() != 0 {
+			t.Error("hash expected to be invalidated")
+		}
+
+		s := b.snapshot()
+		if len(s.jsonInput.Items) != 2 || len(s.uItems) != 2 || s.uItems[1].ID != 3 || s.jsonInput.Items[1].ID != 3 {
+			t.Errorf("items out of sync: %v, %v", s.jsonInput.Items, s.uItems)
+		}
+
+		var ids []int
+		b.Contains("b", &ids)
+		if len(ids) != 0 {
+			t.Errorf("expected no items, got %v", ids)
+		}
+
+		if err := b.Optimize(); err != nil {
+			t.Fatal(err)
+		}
+		if string(b.JSON()) != `{"items":[{"id":1,"name":"A"},{"id":3,"name":"C"}],"hash":"`+strconv.FormatUint(b.Hash(), 10)+`"}` {
+			t.Errorf("unexpected JSON %s", b.JSON())
+		}
+	}
+}
+
+func TestBook_Replace(t *testing.T) {
+
+	b := NewConcurrentBook()
+	b.Set(1, "A")
+	b.Set(2, "B")
+
+	old := b.snapshot()
+	if err := b.Replace([]Item{{ID: 3, Name: "C"}, {ID: 4, Name: "D"}, {ID: 3, Name: "CC"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if b.IsExist(1) || b.Len() != 2 || b.Name(0, 3) != "CC" {
+		t.Errorf("unexpected content %v", b.snapshot().jsonInput.Items)
+	}
+	if s := b.snapshot(); len(s.jsonInput.Items) != 2 || len(s.uItems) != 2 {
+		t.Errorf("items out of sync: %v, %v", s.jsonInput.Items, s.uItems)
+	}
+	if b.Hash() == 0 {
+		t.Error("expected optimized book")
+	}
+	if len(old.m) != 2 || old.m[1] != "A" {
+		t.Error("published snapshot has been modified")
+	}
+}
+
+func TestFlexBook_BookReadOnly(t *testing.T) {
+
+	for _, b := range []*FlexBook{NewFlexBook(), NewFlexBook(WithThreadSafe())} {
+		b.AddMultiLangItem(MultiLangItem{ID: 1, Name: map[string]string{"en": "A"}})
+
+		bk := b.Book("en")
+		if err := bk.Replace([]Item{{ID: 2, Name: "B"}}); err != ErrReadOnly {
+			t.Errorf("expected ErrReadOnly, got %v", err)
+		}
+		if err := bk.Parse([]byte(`[{"id":2,"name":"B"}]`)); err != ErrReadOnly {
+			t.Errorf("expected ErrReadOnly, got %v", err)
+		}
+		bk.Set(1, "AA")
+		bk.Set(2, "B")
+		bk.Delete(1)
+		bk.SetActive(1, false)
+		if bk.Len() != 1 || bk.Name(0, 1) != "A" || len(bk.ActiveItems()) != 1 || b.Name(0, 1) != "A" {
+			t.Error("read only book must not be changed")
+		}
+
+		// changes are made through FlexBook.
+		b.AddMultiLangItem(MultiLangItem{ID: 1, Name: map[string]string{"en": "AA"}})
+		if name := b.Book("en").Name(0, 1); name != "AA" {
+			t.Errorf("expected AA, got %s", name)
+		}
+	}
+}
+
+func TestBook_AutoOptimize(t *testing.T) {
+
+	for _, b := range []*Book{NewBook(WithAutoOptimize(0)), NewConcurrentBook(WithAutoOptimize(0))} {
+		if string(b.JSON()) != `{"items":[],"hash":"`+strconv.FormatUint(b.Hash(), 10)+`"}` {
+			t.Errorf("unexpected JSON of empty book %s", b.JSON())
+		}
+
+		b.Set(1, "A")
+		h := b.Hash()
+		if h == 0 {
+			t.Error("expected hash")
+		}
+		if string(b.JSON()) != `{"items":[{"id":1,"name":"A"}],"hash":"`+strconv.FormatUint(h, 10)+`"}` {
+			t.Errorf("unexpected JSON %s", b.JSON())
+		}
+
+		b.Set(1, "B")
+		if b.Hash() == h || b.Hash() == 0 {
+			t.Error("expected new hash")
+		}
+
+		b.Delete(1)
+		if string(b.JSON()) != `{"items":[],"hash":"`+strconv.FormatUint(b.Hash(), 10)+`"}` {
+			t.Errorf("unexpected JSON %s", b.JSON())
+		}
+	}
+
+	// without auto optimization JSON stays stale.
+	b := NewBook()
+	b.Set(1, "A")
+	if b.JSON() != nil || b.Hash() != 0 {
+		t.Error("unexpected optimization")
+	}
+}
+
+func TestBook_AutoOptimizeConcurrent(t *testing.T) {
+
+	b := NewConcurrentBook(WithAutoOptimize(0))
+
+	var wg sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				if b.Hash() == 0 || len(b.JSON()) == 0 {
+					t.Error("expected compiled book")
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 200; i++ {
+		b.Set(i, strconv.Itoa(i))
+	}
+	wg.Wait()
+
+	if !strings.Contains(string(b.JSON()), `{"id":199,"name":"199"}`) {
+		t.Error("last item not found in JSON")
+	}
+}
+
+// TestBook_AutoOptimizeConcurrentReads runs concurrent readers of not
+// concurrent book after a change, run it with -race.
+func TestBook_AutoOptimizeConcurrentReads(t *testing.T) {
+
+	b := NewBook(WithAutoOptimize(0))
+	fb := NewFlexBook(WithAutoOptimize(0))
+	if err := fb.Parse([]byte(`[{"id":1,"name":{"en":"A","ru":"AA"}}]`)); err != nil {
+		t.Fatal(err)
+	}
+	b.Set(1, "A")
+	fb.AddMultiLangItem(MultiLangItem{ID: 2, Name: map[string]string{"en": "B", "ru": "BB"}})
+
+	var wg sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var buf []byte
+			for i := 0; i < 50; i++ {
+				if b.Hash() == 0 || len(b.JSON()) == 0 || fb.Hash("ru") == 0 {
+					t.Error("expected compiled book")
+					return
+				}
+				buf = buf[:0]
+				fb.BookAsJSON("en", &buf)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if !strings.Contains(string(b.JSON()), `{"id":1,"name":"A"}`) {
+		t.Error("item not found in JSON")
+	}
+}
+
+func TestBook_AutoOptimizeDebounce(t *testing.T) {
+
+	b := NewConcurrentBook(WithAutoOptimize(20 * time.Millisecond))
+	for i := 0; i < 100; i++ {
+		b.Set(i, strconv.Itoa(i))
+	}
+
+	if !b.snapshot().isCompileRequired {
+		t.Error("book must not be optimized during the burst")
+	}
+
+	waitFor(t, "optimization in background", func() bool {
+		s := b.snapshot()
+		return !s.isCompileRequired && s.jsonInput.Hash != 0
+	})
+}
+
+func TestBook_IDByName(t *testing.T) {
+
+	b := NewConcurrentBook()
+	if err := b.Parse([]byte(`[{"id":1,"name":"Individual"},{"id":2,"name":"Organization"},{"id":3,"name":"Other"},{"id":4,"name":"OTHER"}]`)); err != nil {
+		t.Fatal(err)
+	}
+
+	tc := []struct {
+		name        string
+		m           NameMatch
+		expected    int
+		expectedErr error
+	}{
+		{"Organization", MatchExact, 2, nil},
+		{"organization", MatchExact, 0, ErrNameNotFound},
+		{"organization", MatchCaseInsensitive, 2, nil},
+		{"Other", MatchExact, 3, nil},
+		{"other", MatchCaseInsensitive, 0, ErrAmbiguousName},
+		{"Unknown", MatchCaseInsensitive, 0, ErrNameNotFound},
+	}
+
+	for i := range tc {
+		t.Run(tc[i].name, func(t *testing.T) {
+			id, err := b.IDByName(0, tc[i].name, tc[i].m)
+			if id != tc[i].expected || err != tc[i].expectedErr {
+				t.Errorf("expected %d %v, got %d %v", tc[i].expected, tc[i].expectedErr, id, err)
+			}
+		})
+	}
+
+	if ids := b.IDsByName(0, "other", MatchCaseInsensitive); len(ids) != 2 || ids[0] != 3 || ids[1] != 4 {
+		t.Errorf("expected [3 4], got %v", ids)
+	}
+
+	// index follows changes.
+	b.Set(4, "Another")
+	if id, err := b.IDByName(0, "other", MatchCaseInsensitive); err != nil || id != 3 {
+		t.Errorf("expected 3 after rename, got %d %v", id, err)
+	}
+	b.Delete(2)
+	if _, err := b.IDByName(0, "Organization", MatchExact); err != ErrNameNotFound {
+		t.Error("deleted item must not be found")
+	}
+	b.Set(5, "Organization")
+	if id, err := b.IDByName(0, "Organization", MatchExact); err != nil || id != 5 {
+		t.Errorf("expected 5, got %d %v", id, err)
+	}
+}
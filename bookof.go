@@ -0,0 +1,968 @@
+package refbook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mitchellh/hashstructure"
+)
+
+// BookOf implements in-memory storage of reference book in a single language
+// with keys of type K and payloads of type V.
+//
+// Name of the item is V itself if V is a string, result of String() if
+// V implements fmt.Stringer and fmt.Sprint(V) otherwise.
+//
+// Content of the book is kept in the immutable snapshot. Writers of
+// concurrent book build a new snapshot and swap it in, so readers never
+// block and always see a consistent view.
+type BookOf[K comparable, V any] struct {
+	isConcurrent bool
+	nameOf       func(V) string
+	rowOf        func(ItemOf[K, V]) interface{} // replaces items in JSON if set.
+	mux          sync.Mutex                     // serializes writers of concurrent book.
+	snap         atomic.Pointer[bookSnapshot[K, V]]
+	events       dispatcher[EventOf[K]]
+	encoders     []encoder // precompress compiled JSON.
+	history      *history[K, V]
+	sorter       *sorter  // nil keeps order of insertion.
+	withInactive bool     // JSON, Contains and Search include inactive items.
+	isReadOnly   bool     // language book of FlexBook changed through FlexBook only.
+	lang         LangCode // set by WithDefaultLang, zero means global default.
+
+	isAutoOptimize bool
+	debounce       time.Duration
+	timer          *time.Timer // postponed optimization, guarded by mux.
+}
+
+// bookSnapshot holds content of the book.
+type bookSnapshot[K comparable, V any] struct {
+	m         map[K]V
+	uItems    []nameItem[K]  // in order of jsonInput.Items.
+	byName    map[string][]K // ids by name.
+	byUName   map[string][]K // ids by name in upper case.
+	byFName   map[string][]K // ids by folded name.
+	jsonInput struct {
+		Items []ItemOf[K, V] `json:"items"`
+		Hash  uint64         `json:"hash,string" hash:"ignore"`
+	}
+	periods           map[K][]ItemOf[K, V] // versions of items having validity, by ValidFrom.
+	isCompileRequired bool
+	jsonCompiled      []byte
+	jsonEncoded       []encodedJSON // jsonCompiled compressed by encoders.
+	ver               uint64        // incremented on every change.
+}
+
+// nameItem keeps name of the item in forms used by lookups.
+type nameItem[K comparable] struct {
+	ID    K
+	Name  string
+	UName string  // name in upper case.
+	FName string  // name folded, insensitive to case and diacritics.
+	fOffs []int32 // offsets in Name of bytes of FName, see foldOffsets.
+	key   []byte  // collation key of Name, set by sort.
+
+	aliases []nameItem[K] // forms of alias names.
+
+	inactive bool
+	fallback bool // name is taken from another language or is NotFoundName.
+}
+
+func newBookSnapshot[K comparable, V any](size int) *bookSnapshot[K, V] {
+	s := bookSnapshot[K, V]{m: make(map[K]V, size), isCompileRequired: true}
+	s.uItems = make([]nameItem[K], 0, size)
+	s.byName = make(map[string][]K, size)
+	s.byUName = make(map[string][]K, size)
+	s.byFName = make(map[string][]K, size)
+	s.jsonInput.Items = make([]ItemOf[K, V], 0, size)
+	return &s
+}
+
+// clone returns deep copy of the snapshot. Compiled JSON and slices of
+// ids by name are shared because they are never modified.
+func (s *bookSnapshot[K, V]) clone() *bookSnapshot[K, V] {
+	c := *s
+	c.m = make(map[K]V, len(s.m))
+	for k, v := range s.m {
+		c.m[k] = v
+	}
+	c.byName = make(map[string][]K, len(s.byName))
+	for k, v := range s.byName {
+		c.byName[k] = v
+	}
+	c.byUName = make(map[string][]K, len(s.byUName))
+	for k, v := range s.byUName {
+		c.byUName[k] = v
+	}
+	c.byFName = make(map[string][]K, len(s.byFName))
+	for k, v := range s.byFName {
+		c.byFName[k] = v
+	}
+	if s.periods != nil {
+		c.periods = make(map[K][]ItemOf[K, V], len(s.periods))
+		for k, v := range s.periods {
+			c.periods[k] = v
+		}
+	}
+	c.uItems = append(make([]nameItem[K], 0, len(s.uItems)), s.uItems...)
+	c.jsonInput.Items = append(make([]ItemOf[K, V], 0, len(s.jsonInput.Items)), s.jsonInput.Items...)
+	return &c
+}
+
+// position returns index of the item in jsonInput.Items. Returns -1 if
+// item not found.
+func (s *bookSnapshot[K, V]) position(id K) int {
+	if _, ok := s.m[id]; !ok {
+		return -1
+	}
+	for i := range s.jsonInput.Items {
+		if s.jsonInput.Items[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// set inserts/updates the item having the name, fallback tells the name
+// is not in the language of the book. Returns false if the item is not
+// changed.
+func (s *bookSnapshot[K, V]) set(item ItemOf[K, V], name string, fallback bool) bool {
+	ni := newNameItem(item.ID, name, item.Aliases, item.Inactive)
+	ni.fallback = fallback
+
+	if i := s.position(item.ID); i != -1 {
+		if equalItems(s.jsonInput.Items[i], item) {
+			if s.uItems[i].fallback != fallback {
+				s.unindex(s.uItems[i])
+				s.uItems[i] = ni
+				s.index(ni)
+			}
+			return false
+		}
+		s.m[item.ID] = item.Name
+		s.jsonInput.Items[i] = item
+		s.unindex(s.uItems[i])
+		s.uItems[i] = ni
+	} else {
+		s.m[item.ID] = item.Name
+		s.jsonInput.Items = append(s.jsonInput.Items, item)
+		s.uItems = append(s.uItems, ni)
+	}
+	s.index(ni)
+
+	s.touch()
+	return true
+}
+
+// touch marks the snapshot as changed.
+func (s *bookSnapshot[K, V]) touch() {
+	s.isCompileRequired = true
+	s.jsonInput.Hash = 0
+	s.ver++
+}
+
+// delete removes the item. Returns false if item not found.
+func (s *bookSnapshot[K, V]) delete(id K) bool {
+	if _, ok := s.m[id]; !ok {
+		return false
+	}
+	delete(s.m, id)
+	for i := range s.jsonInput.Items {
+		if s.jsonInput.Items[i].ID == id {
+			s.jsonInput.Items = append(s.jsonInput.Items[:i], s.jsonInput.Items[i+1:]...)
+			s.unindex(s.uItems[i])
+			s.uItems = append(s.uItems[:i], s.uItems[i+1:]...)
+			break
+		}
+	}
+	delete(s.periods, id)
+	s.touch()
+	return true
+}
+
+// appendID returns copy of ids with id appended, so ids shared with
+// other snapshots are not modified. Returns ids as they are if id is
+// already there, for instance alias differs from the name by case.
+func appendID[K comparable](ids []K, id K) []K {
+	if slices.Contains(ids, id) {
+		return ids
+	}
+	return append(ids[:len(ids):len(ids)], id)
+}
+
+// removeID removes id from ids by key, ids are copied.
+func removeID[K comparable](m map[string][]K, key string, id K) {
+	ids := m[key]
+	for i := range ids {
+		if ids[i] != id {
+			continue
+		}
+		if len(ids) == 1 {
+			delete(m, key)
+			return
+		}
+		m[key] = append(append(make([]K, 0, len(ids)-1), ids[:i]...), ids[i+1:]...)
+		return
+	}
+}
+
+// set sets the item of s, fallback tells the name is taken from another
+// language. Item having validity interval is added as a version of the
+// item, see ItemOf. Returns event describing the change, false if nothing
+// changed.
+func (b *BookOf[K, V]) set(s *bookSnapshot[K, V], item ItemOf[K, V], fallback bool) (EventOf[K], bool) {
+	ov, ok := s.m[item.ID]
+
+	isPeriodChanged := false
+	if vs, isBounded := s.periods[item.ID]; isBounded || item.isBounded() {
+		item, isPeriodChanged = s.setVersion(vs, item)
+	}
+
+	name := b.nameOf(item.Name)
+	if !s.set(item, name, fallback) {
+		if !isPeriodChanged {
+			return EventOf[K]{}, false
+		}
+		s.touch()
+	}
+
+	e := EventOf[K]{Type: EventAdded, ID: item.ID, NewName: name}
+	if ok {
+		e.Type = EventUpdated
+		e.OldName = b.nameOf(ov)
+	}
+	return e, true
+}
+
+// delete removes the item from s. Returns event describing the change,
+// false if item not found.
+func (b *BookOf[K, V]) delete(s *bookSnapshot[K, V], id K) (EventOf[K], bool) {
+	ov, ok := s.m[id]
+	if !ok {
+		return EventOf[K]{}, false
+	}
+	s.delete(id)
+	return EventOf[K]{Type: EventDeleted, ID: id, OldName: b.nameOf(ov)}, true
+}
+
+// diff returns events turning snapshot old into s.
+func (b *BookOf[K, V]) diff(old, s *bookSnapshot[K, V]) []EventOf[K] {
+	var res []EventOf[K]
+	for _, item := range s.jsonInput.Items {
+		ov, ok := old.m[item.ID]
+		switch {
+		case !ok:
+			res = append(res, EventOf[K]{Type: EventAdded, ID: item.ID, NewName: b.nameOf(item.Name)})
+		case !equal(ov, item.Name):
+			res = append(res, EventOf[K]{Type: EventUpdated, ID: item.ID, OldName: b.nameOf(ov), NewName: b.nameOf(item.Name)})
+		}
+	}
+	for _, item := range old.jsonInput.Items {
+		if _, ok := s.m[item.ID]; !ok {
+			res = append(res, EventOf[K]{Type: EventDeleted, ID: item.ID, OldName: b.nameOf(item.Name)})
+		}
+	}
+	return res
+}
+
+// hashItems returns hash of items and versions of items having validity.
+// Hash does not depend on order of items: items are hashed one by one
+// and hashes of items are sorted.
+func hashItems[K comparable, V any](items []ItemOf[K, V], periods map[K][]ItemOf[K, V]) (uint64, error) {
+	ih, err := itemHashes(items)
+	if err != nil {
+		return 0, err
+	}
+
+	var versions []ItemOf[K, V]
+	for _, vs := range periods {
+		versions = append(versions, vs...)
+	}
+	vh, err := itemHashes(versions)
+	if err != nil {
+		return 0, err
+	}
+
+	return hashstructure.Hash(struct {
+		Items    []uint64
+		Versions []uint64
+	}{ih, vh}, nil)
+}
+
+// itemHashes returns sorted hashes of items. Validity bounds are hashed
+// in UTC, the same instant in other location gives the same hash.
+func itemHashes[K comparable, V any](items []ItemOf[K, V]) ([]uint64, error) {
+	res := make([]uint64, len(items))
+	for i := range items {
+		item := items[i]
+		item.ValidFrom, item.ValidTo = item.ValidFrom.UTC(), item.ValidTo.UTC()
+		h, err := hashstructure.Hash(item, nil)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = h
+	}
+	slices.Sort(res)
+	return res, nil
+}
+
+// marshal returns JSON object with items and hash. Inactive items are
+// skipped unless option WithInactive is given.
+func (b *BookOf[K, V]) marshal(items []ItemOf[K, V], h uint64) ([]byte, error) {
+	items = b.jsonItems(items)
+	var rows interface{} = items
+	if b.rowOf != nil {
+		r := make([]interface{}, len(items))
+		for i := range r {
+			r[i] = b.rowOf(items[i])
+		}
+		rows = r
+	}
+	return json.Marshal(struct {
+		Items interface{} `json:"items"`
+		Hash  uint64      `json:"hash,string"`
+	}{rows, h})
+}
+
+// optimize calculates hash and pre-generates JSON of the snapshot.
+func (b *BookOf[K, V]) optimize(s *bookSnapshot[K, V]) error {
+
+	s.sort(b.sorter)
+
+	h, err := hashItems(s.jsonInput.Items, s.periods)
+	if err != nil {
+		return err
+	}
+
+	s.jsonInput.Hash = h
+	if s.jsonCompiled, err = b.marshal(s.jsonInput.Items, h); err != nil {
+		return err
+	}
+
+	if err := s.encode(b.encoders); err != nil {
+		return err
+	}
+
+	s.isCompileRequired = false
+	b.history.add(h, b.jsonItems(s.jsonInput.Items))
+	return nil
+}
+
+// equalItems compares items having the same id.
+func equalItems[K comparable, V any](a, b ItemOf[K, V]) bool {
+	return a.SortOrder == b.SortOrder && a.Inactive == b.Inactive &&
+		a.ValidFrom.Equal(b.ValidFrom) && a.ValidTo.Equal(b.ValidTo) &&
+		slices.Equal(a.Aliases, b.Aliases) && equal(a.Name, b.Name)
+}
+
+// equal compares payloads. Payload can be not comparable, for instance
+// a struct with slice inside.
+func equal[V any](a, b V) bool {
+	if s, ok := any(a).(string); ok {
+		return s == any(b).(string)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// defaultName returns name of the payload.
+func defaultName[V any](v V) string {
+	switch x := any(v).(type) {
+	case string:
+		return x
+	case fmt.Stringer:
+		return x.String()
+	}
+	return fmt.Sprint(v)
+}
+
+// stringName is nameOf of string payloads, it avoids allocation caused
+// by conversion of the payload to interface in defaultName.
+func stringName(s string) string {
+	return s
+}
+
+// NewBookOf returns new instance of concurrent unsafe BookOf.
+// Use this function if you does not expect items modification
+// in runtime.
+func NewBookOf[K comparable, V any](f ...func(*Option)) *BookOf[K, V] {
+	b := BookOf[K, V]{}
+	b.init(newOption(f))
+	return &b
+}
+
+// NewConcurrentBookOf returns new instance of concurrent safe BookOf.
+func NewConcurrentBookOf[K comparable, V any](f ...func(*Option)) *BookOf[K, V] {
+	o := newOption(f)
+	o.isConcurrent = true
+
+	b := BookOf[K, V]{}
+	b.init(o)
+	return &b
+}
+
+func (b *BookOf[K, V]) init(o Option) {
+	b.isConcurrent = o.isConcurrent
+	b.isAutoOptimize = o.isAutoOptimize
+	b.debounce = o.debounce
+	b.encoders = o.encoders
+	b.history = newHistory[K, V](o.historyDepth)
+	b.withInactive = o.withInactive
+	if o.lang != "" {
+		b.lang = ToLangCode(o.lang)
+	}
+	if o.order != OrderInsertion {
+		b.sorter = newSorter(o.order, b.langCode())
+	}
+	b.nameOf = defaultName[V]
+	if f, ok := any(stringName).(func(V) string); ok {
+		b.nameOf = f
+	}
+	b.snap.Store(newBookSnapshot[K, V](0))
+}
+
+// initFrom inits empty book with configuration of src.
+func (b *BookOf[K, V]) initFrom(src *BookOf[K, V]) {
+	b.isConcurrent = src.isConcurrent
+	b.nameOf = src.nameOf
+	b.rowOf = src.rowOf
+	b.encoders = src.encoders
+	b.history = src.history
+	b.sorter = src.sorter
+	b.withInactive = src.withInactive
+	b.isAutoOptimize = src.isAutoOptimize
+	b.debounce = src.debounce
+	b.isReadOnly = src.isReadOnly
+	b.lang = src.lang
+	b.snap.Store(newBookSnapshot[K, V](0))
+}
+
+// langCode returns language of the book set by WithDefaultLang or global
+// default language.
+func (b *BookOf[K, V]) langCode() LangCode {
+	if b.lang != 0 {
+		return b.lang
+	}
+	mux.RLock()
+	defer mux.RUnlock()
+	return defaultLangCode
+}
+
+// SetAutoOptimize turns on automatic optimization, see WithAutoOptimize.
+// It has to be called before the book is used.
+func (b *BookOf[K, V]) SetAutoOptimize(debounce time.Duration) {
+	b.isAutoOptimize = true
+	b.debounce = debounce
+}
+
+// snapshot returns current content of the book.
+func (b *BookOf[K, V]) snapshot() *bookSnapshot[K, V] {
+	return b.snap.Load()
+}
+
+// mutate calls f with the book content f can modify. Concurrent book
+// passes to f a copy of the current snapshot and publishes it if f
+// reports changes. Events pushed by f are delivered after the lock is
+// released. Does nothing if the book is read only.
+func (b *BookOf[K, V]) mutate(f func(s *bookSnapshot[K, V]) bool) {
+	if b.isReadOnly {
+		return
+	}
+	defer b.events.flush()
+
+	if !b.isConcurrent {
+		s := b.snapshot()
+		f(s)
+		s.sort(b.sorter)
+		return
+	}
+
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	s := b.snapshot().clone()
+	if f(s) {
+		s.sort(b.sorter)
+		b.snap.Store(s)
+		b.scheduleOptimize()
+	}
+}
+
+// Subscribe adds function called on every change of the book item.
+// Events are delivered in order of changes, after the book lock has been
+// released, so f can access the book. Change made by f is delivered
+// after f returns.
+func (b *BookOf[K, V]) Subscribe(f func(EventOf[K])) {
+	b.events.subscribe(f)
+}
+
+// scheduleOptimize postpones optimization of concurrent book until
+// changes stop coming for debounce duration. Requires b.mux to be locked.
+func (b *BookOf[K, V]) scheduleOptimize() {
+	if !b.isAutoOptimize || b.debounce <= 0 {
+		return
+	}
+
+	if b.timer == nil {
+		b.timer = time.AfterFunc(b.debounce, func() {
+			_ = b.optimizeIfRequired(false)
+		})
+		return
+	}
+	b.timer.Reset(b.debounce)
+}
+
+// compiled returns the current snapshot. Auto optimized book
+// recompiles the snapshot if it has been changed. The snapshot is never
+// compiled in place, readers can run concurrently.
+func (b *BookOf[K, V]) compiled() *bookSnapshot[K, V] {
+	s := b.snapshot()
+	if b.isAutoOptimize && s.isCompileRequired {
+		s, _ = b.recompile(false)
+	}
+	return s
+}
+
+func (b *BookOf[K, V]) name(id K) (string, bool) {
+	v, ok := b.snapshot().m[id]
+	if !ok {
+		return "", false
+	}
+	return b.nameOf(v), true
+}
+
+// Get returns payload of the item by id.
+func (b *BookOf[K, V]) Get(id K) (V, bool) {
+	v, ok := b.snapshot().m[id]
+	return v, ok
+}
+
+// IsExist return true if item with id exists.
+func (b *BookOf[K, V]) IsExist(id K) bool {
+	_, ok := b.snapshot().m[id]
+	return ok
+}
+
+// Set inserts/update reference book item.
+// Does nothing if an item exist. Other attributes of the item are kept.
+func (b *BookOf[K, V]) Set(id K, v V) {
+	if ov, ok := b.Get(id); ok && equal(ov, v) {
+		return
+	}
+
+	b.mutate(func(s *bookSnapshot[K, V]) bool {
+		item := ItemOf[K, V]{ID: id}
+		if i := s.position(id); i != -1 {
+			item = s.jsonInput.Items[i]
+		}
+		item.Name = v
+		e, ok := b.set(s, item, false)
+		if ok {
+			b.events.push(e)
+		}
+		return ok
+	})
+}
+
+// Delete removes reference book item.
+// Does nothing if an item does not exist.
+func (b *BookOf[K, V]) Delete(id K) {
+	if !b.IsExist(id) {
+		return
+	}
+
+	b.mutate(func(s *bookSnapshot[K, V]) bool {
+		e, ok := b.delete(s, id)
+		if ok {
+			b.events.push(e)
+		}
+		return ok
+	})
+}
+
+// Replace replaces all items of the book by items at once.
+// If items have the same id, the last one wins.
+func (b *BookOf[K, V]) Replace(items []ItemOf[K, V]) error {
+	return b.load(items)
+}
+
+// Optimize calculates hash and pre-generates JSON.
+func (b *BookOf[K, V]) Optimize() error {
+	return b.optimizeIfRequired(true)
+}
+
+// optimizeIfRequired optimizes the book if it has been changed since
+// the last optimization or force is true. Optimize of not concurrent book
+// modifies the snapshot in place, lazy optimization on read builds a new
+// one because readers of any book can run concurrently.
+func (b *BookOf[K, V]) optimizeIfRequired(force bool) error {
+	if !b.isConcurrent && force {
+		return b.optimize(b.snapshot())
+	}
+	_, err := b.recompile(force)
+	return err
+}
+
+// recompile publishes optimized copy of the current snapshot if it has
+// been changed or force is true. Returns the published snapshot.
+func (b *BookOf[K, V]) recompile(force bool) (*bookSnapshot[K, V], error) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	cur := b.snapshot()
+	if !force && !cur.isCompileRequired {
+		return cur, nil
+	}
+
+	// items are not changed, shallow copy is enough.
+	s := *cur
+	if err := b.optimize(&s); err != nil {
+		return cur, err
+	}
+	b.snap.Store(&s)
+	return &s, nil
+}
+
+// JSON returns items as JSON array [{"id":1, "name" :"aaaa"},...].
+func (b *BookOf[K, V]) JSON() []byte {
+	return b.compiled().jsonCompiled
+}
+
+// Hash returns hash taken out of all items.
+func (b *BookOf[K, V]) Hash() uint64 {
+	return b.compiled().jsonInput.Hash
+}
+
+// Len returns items count.
+func (b *BookOf[K, V]) Len() int {
+	return len(b.snapshot().m)
+}
+
+// Name return reference book item's name by id.
+// Returns variable NotFoundName if id is not found.
+func (b *BookOf[K, V]) Name(lc LangCode, id K) string {
+	if b == nil {
+		return NotFoundName
+	}
+
+	if res, ok := b.name(id); ok {
+		return res
+	}
+	return NotFoundName
+}
+
+// Traverse walks through the reference book items in order given by
+// option WithOrder. Calls f() for each element.
+// Aborts traverse if f() return false.
+func (b *BookOf[K, V]) Traverse(f func(id K, v V) (next bool)) {
+	for _, item := range b.snapshot().jsonInput.Items {
+		if next := f(item.ID, item.Name); !next {
+			break
+		}
+	}
+}
+
+// Items returns copy of the book items in order given by option WithOrder.
+func (b *BookOf[K, V]) Items() []ItemOf[K, V] {
+	return append([]ItemOf[K, V](nil), b.snapshot().jsonInput.Items...)
+}
+
+// Contains adds to dst ID of reference book items if name or alias
+// contains s. The function is case unsensitive. Inactive items are
+// skipped unless option WithInactive is given.
+func (b *BookOf[K, V]) Contains(s string, dst *[]K) {
+	b.ContainsMatch(s, MatchCaseInsensitive, dst)
+}
+
+// ContainsMatch adds to dst ID of reference book items if name or alias
+// contains s compared according to m.
+func (b *BookOf[K, V]) ContainsMatch(s string, m NameMatch, dst *[]K) {
+	*dst = (*dst)[:0]
+	if len(s) == 0 {
+		return
+	}
+
+	s = m.normalize(s)
+	uItems := b.snapshot().uItems
+	for i := range uItems {
+		if uItems[i].inactive && !b.withInactive {
+			continue
+		}
+		if uItems[i].contains(s, m) {
+			*dst = append(*dst, uItems[i].ID)
+		}
+	}
+}
+
+// NameMatch defines how names are compared by IDByName and ContainsMatch.
+type NameMatch int
+
+const (
+	MatchExact NameMatch = iota
+	MatchCaseInsensitive
+
+	// MatchFold uses Unicode case folding and ignores diacritics, so
+	// "societe" matches "Société" and "strasse" matches "Straße".
+	MatchFold
+)
+
+// normalize returns s in the form compared by m.
+func (m NameMatch) normalize(s string) string {
+	switch m {
+	case MatchCaseInsensitive:
+		return strings.ToUpper(s)
+	case MatchFold:
+		return fold(s)
+	}
+	return s
+}
+
+// form returns name of the item in the form compared by m.
+func (ni *nameItem[K]) form(m NameMatch) string {
+	switch m {
+	case MatchCaseInsensitive:
+		return ni.UName
+	case MatchFold:
+		return ni.FName
+	}
+	return ni.Name
+}
+
+// IDByName returns id of the item by its name or alias. Returns
+// ErrNameNotFound if name is not found and ErrAmbiguousName if several
+// items have the name, use IDsByName to get all of them. An error is
+// returned instead of a bool so that both cases can be told apart.
+// The book holds names of a single language, lc is not used; it keeps
+// the signature shared with FlexBook.
+func (b *BookOf[K, V]) IDByName(lc LangCode, name string, m NameMatch) (K, error) {
+	var zero K
+	switch ids := b.snapshot().idsByName(name, m); len(ids) {
+	case 0:
+		return zero, ErrNameNotFound
+	case 1:
+		return ids[0], nil
+	}
+	return zero, ErrAmbiguousName
+}
+
+// IDsByName returns ids of all items having the name.
+func (b *BookOf[K, V]) IDsByName(lc LangCode, name string, m NameMatch) []K {
+	return append([]K(nil), b.snapshot().idsByName(name, m)...)
+}
+
+func (s *bookSnapshot[K, V]) idsByName(name string, m NameMatch) []K {
+	switch m {
+	case MatchCaseInsensitive:
+		return s.byUName[m.normalize(name)]
+	case MatchFold:
+		return s.byFName[m.normalize(name)]
+	}
+	return s.byName[name]
+}
+
+// convertible returns true if value of type from can be converted to type to.
+// Integer to string conversion gives a rune, it's not expected.
+func convertible(from, to reflect.Type) bool {
+	if !from.ConvertibleTo(to) {
+		return false
+	}
+	return to.Kind() != reflect.String || from.Kind() == reflect.String || from.Kind() == reflect.Slice
+}
+
+// fieldAs returns value of struct field converted to type T.
+func fieldAs[T any](f reflect.Value) (T, error) {
+	var res T
+	t := reflect.TypeOf(&res).Elem()
+
+	if !convertible(f.Type(), t) {
+		return res, fmt.Errorf("attribute of type %s can't be used as %s", f.Type(), t)
+	}
+	reflect.ValueOf(&res).Elem().Set(f.Convert(t))
+	return res, nil
+}
+
+// SliceOption holds LoadFromSlice configuration.
+type SliceOption struct {
+	validFromAttr string
+	validToAttr   string
+}
+
+// WithValidityAttrs informs that validity interval of items is taken from
+// struct fields of type time.Time or *time.Time, see ItemOf.
+func WithValidityAttrs(from, to string) func(o *SliceOption) {
+	return func(o *SliceOption) {
+		o.validFromAttr = from
+		o.validToAttr = to
+	}
+}
+
+func newSliceOption(f []func(*SliceOption)) SliceOption {
+	o := SliceOption{}
+	for i := range f {
+		f[i](&o)
+	}
+	return o
+}
+
+// validity returns validity interval given by fields of struct v.
+func (o *SliceOption) validity(v reflect.Value) (from, to time.Time, err error) {
+	if from, err = timeAttr(v, o.validFromAttr); err != nil {
+		return
+	}
+	to, err = timeAttr(v, o.validToAttr)
+	return
+}
+
+// timeAttr returns value of struct field attr of type time.Time or
+// *time.Time. Returns zero time if attr is empty or field is nil.
+func timeAttr(v reflect.Value, attr string) (time.Time, error) {
+	if attr == "" {
+		return time.Time{}, nil
+	}
+
+	f := v.FieldByName(attr)
+	if !f.IsValid() {
+		return time.Time{}, fmt.Errorf("attribute %s not found", attr)
+	}
+
+	switch t := f.Interface().(type) {
+	case time.Time:
+		return t, nil
+	case *time.Time:
+		if t == nil {
+			return time.Time{}, nil
+		}
+		return *t, nil
+	}
+	return time.Time{}, fmt.Errorf("attribute of type %s can't be used as time.Time", f.Type())
+}
+
+// LoadFromSlice init reference book with id, name pairs from any slice.
+func (b *BookOf[K, V]) LoadFromSlice(slice interface{}, attrid string, attrname string, f ...func(*SliceOption)) error {
+
+	if slice == nil {
+		return nil
+	}
+
+	s := reflect.ValueOf(slice)
+	if s.Kind() == reflect.Ptr {
+		s = s.Elem()
+	}
+
+	if s.Kind() != reflect.Slice {
+		return errors.New("expected argument as reference to slice")
+	}
+
+	if s.Len() == 0 {
+		return nil
+	}
+
+	if !s.Index(0).FieldByName(attrid).IsValid() {
+		return fmt.Errorf("attribute %s not found", attrid)
+	}
+
+	if !s.Index(0).FieldByName(attrname).IsValid() {
+		return fmt.Errorf("attribute %s not found", attrname)
+	}
+
+	if b.isReadOnly {
+		return ErrReadOnly
+	}
+
+	o := newSliceOption(f)
+	items := make([]ItemOf[K, V], s.Len())
+	for i := range items {
+		item := s.Index(i)
+		var err error
+		if items[i].ID, err = fieldAs[K](item.FieldByName(attrid)); err != nil {
+			return err
+		}
+		if items[i].Name, err = fieldAs[V](item.FieldByName(attrname)); err != nil {
+			return err
+		}
+		if items[i].ValidFrom, items[i].ValidTo, err = o.validity(item); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	b.mutate(func(bs *bookSnapshot[K, V]) bool {
+		if err = checkPeriods(bs, items); err != nil {
+			return false
+		}
+
+		var evs []EventOf[K]
+		for i := range items {
+			if e, ok := b.set(bs, items[i], false); ok {
+				evs = append(evs, e)
+			}
+		}
+		if err = b.optimize(bs); err != nil {
+			return false
+		}
+		for i := range evs {
+			b.events.push(evs[i])
+		}
+		return true
+	})
+	return err
+}
+
+// Parse parses JSON array with objects [{"id": 1, "name": "Hello"},..]
+// and inits
+func (b *BookOf[K, V]) Parse(buf []byte) error {
+
+	var items []ItemOf[K, V]
+
+	err := json.Unmarshal(buf, &items)
+	if err != nil {
+		return err
+	}
+
+	return b.load(items)
+}
+
+// load replaces all items of the book by items.
+func (b *BookOf[K, V]) load(items []ItemOf[K, V]) error {
+
+	if b.isReadOnly {
+		return ErrReadOnly
+	}
+
+	if err := checkPeriods[K, V](nil, items); err != nil {
+		return err
+	}
+
+	s := newBookSnapshot[K, V](len(items))
+	for i := range items {
+		b.set(s, items[i], false)
+	}
+
+	if err := b.optimize(s); err != nil {
+		return err
+	}
+
+	defer b.events.flush()
+	if b.isConcurrent {
+		b.mux.Lock()
+		defer b.mux.Unlock()
+	}
+
+	if b.events.active.Load() {
+		for _, e := range b.diff(b.snapshot(), s) {
+			b.events.push(e)
+		}
+	}
+	b.snap.Store(s)
+	return nil
+}
+
+func (b *BookOf[K, V]) MarshalJSON() ([]byte, error) {
+	return b.JSON(), nil
+}
@@ -0,0 +1,135 @@
+package refbook
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestBookOf_StringKeys(t *testing.T) {
+
+	b := NewConcurrentBookOf[string, string]()
+	src := []byte(`[{"id":"USD","name":"US Dollar"},{"id":"EUR","name":"Euro"}]`)
+	if err := b.Parse(src); err != nil {
+		t.Fatal(err)
+	}
+
+	if name := b.Name(0, "EUR"); name != "Euro" {
+		t.Errorf("expected Euro, got %s", name)
+	}
+	if name := b.Name(0, "RUB"); name != NotFoundName {
+		t.Errorf("expected NotFoundName, got %s", name)
+	}
+	if !b.IsExist("USD") || b.IsExist("usd") {
+		t.Error("unexpected IsExist result")
+	}
+
+	var ids []string
+	b.Contains("dOl", &ids)
+	if len(ids) != 1 || ids[0] != "USD" {
+		t.Errorf("expected [USD], got %v", ids)
+	}
+
+	b.Set("RUB", "Ruble")
+	n := 0
+	b.Traverse(func(id string, name string) bool {
+		n++
+		return true
+	})
+	if n != 3 {
+		t.Errorf("expected 3 items, got %d", n)
+	}
+
+	if err := b.Optimize(); err != nil {
+		t.Fatal(err)
+	}
+	if b.Hash() == 0 {
+		t.Error("expected hash")
+	}
+
+	var res struct {
+		Items []ItemOf[string, string] `json:"items"`
+	}
+	if err := json.Unmarshal(b.JSON(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Items) != 3 || res.Items[2].ID != "RUB" {
+		t.Errorf("unexpected JSON %s", b.JSON())
+	}
+}
+
+type testTariff struct {
+	Short string `json:"short"`
+	Rate  float64
+}
+
+func (t testTariff) String() string {
+	return t.Short
+}
+
+func TestBookOf_TypedPayload(t *testing.T) {
+
+	rows := []struct {
+		Code   uint16
+		Tariff testTariff
+	}{
+		{10, testTariff{"Base", 0.1}},
+		{20, testTariff{"Reduced", 0.05}},
+	}
+
+	b := NewBookOf[int64, testTariff]()
+	if err := b.LoadFromSlice(rows, "Code", "Tariff"); err != nil {
+		t.Fatal(err)
+	}
+
+	v, ok := b.Get(20)
+	if !ok || v.Rate != 0.05 {
+		t.Errorf("unexpected payload %v", v)
+	}
+
+	if name := b.Name(0, 10); name != "Base" {
+		t.Errorf("expected Base, got %s", name)
+	}
+
+	var ids []int64
+	b.Contains("redu", &ids)
+	if len(ids) != 1 || ids[0] != 20 {
+		t.Errorf("expected [20], got %v", ids)
+	}
+
+	h := b.Hash()
+	b.Set(10, testTariff{"Base", 0.1})
+	if b.Hash() != h {
+		t.Error("equal payload must not invalidate hash")
+	}
+	b.Set(10, testTariff{"Base", 0.2})
+	if b.Hash() != 0 {
+		t.Error("changed payload must invalidate hash")
+	}
+
+	if err := b.LoadFromSlice(rows, "Tariff", "Code"); err == nil {
+		t.Error("expected error for struct id")
+	}
+}
+
+func TestBook_LoadFromSliceConvert(t *testing.T) {
+
+	rows := []struct {
+		ID   int32
+		Name []byte
+	}{
+		{1, []byte("Audi")},
+		{2, []byte("BMW")},
+	}
+
+	b := NewBook()
+	if err := b.LoadFromSlice(rows, "ID", "Name"); err != nil {
+		t.Fatal(err)
+	}
+	if name := b.Name(0, 2); name != "BMW" {
+		t.Errorf("expected BMW, got %s", name)
+	}
+
+	if err := b.LoadFromSlice(rows, "Name", "ID"); err == nil {
+		t.Error("expected error for int name")
+	}
+}
@@ -0,0 +1,92 @@
+package refbook
+
+import (
+	"bytes"
+	"compress/gzip"
+)
+
+// encoder precompresses compiled JSON.
+type encoder struct {
+	coding string // content coding, "gzip", "br".
+	encode func([]byte) ([]byte, error)
+}
+
+// encodedJSON is compiled JSON compressed by the encoder.
+type encodedJSON struct {
+	coding string
+	data   []byte
+}
+
+// WithGzip informs that Optimize compresses JSON by gzip,
+// see JSONGzip.
+func WithGzip() func(o *Option) {
+	return WithEncoder("gzip", gzipEncode)
+}
+
+// WithEncoder informs that Optimize compresses JSON by encode, result is
+// served by Handler as content coding. For instance brotli can be added by
+//
+//	WithEncoder("br", func(src []byte) ([]byte, error) {
+//		var buf bytes.Buffer
+//		w := brotli.NewWriterLevel(&buf, brotli.BestCompression)
+//		if _, err := w.Write(src); err != nil {
+//			return nil, err
+//		}
+//		err := w.Close()
+//		return buf.Bytes(), err
+//	})
+func WithEncoder(coding string, encode func(src []byte) ([]byte, error)) func(o *Option) {
+	return func(o *Option) {
+		o.encoders = append(o.encoders, encoder{coding: coding, encode: encode})
+	}
+}
+
+func gzipEncode(src []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(src); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encode compresses compiled JSON of the snapshot.
+func (s *bookSnapshot[K, V]) encode(encoders []encoder) error {
+	s.jsonEncoded = nil
+	for _, e := range encoders {
+		buf, err := e.encode(s.jsonCompiled)
+		if err != nil {
+			return err
+		}
+		s.jsonEncoded = append(s.jsonEncoded, encodedJSON{coding: e.coding, data: buf})
+	}
+	return nil
+}
+
+// encoded returns JSON compressed according to content coding.
+func (s *bookSnapshot[K, V]) encoded(coding string) []byte {
+	for i := range s.jsonEncoded {
+		if s.jsonEncoded[i].coding == coding {
+			return s.jsonEncoded[i].data
+		}
+	}
+	return nil
+}
+
+// JSONGzip returns JSON compressed by gzip. Returns nil if the book is
+// created without option WithGzip.
+func (b *BookOf[K, V]) JSONGzip() []byte {
+	return b.compiled().encoded("gzip")
+}
+
+// JSONEncoded returns JSON compressed according to content coding given
+// by option WithEncoder. Returns nil if coding is unknown.
+func (b *BookOf[K, V]) JSONEncoded(coding string) []byte {
+	return b.compiled().encoded(coding)
+}
@@ -0,0 +1,82 @@
+package refbook
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+)
+
+func gunzip(t *testing.T, src []byte) []byte {
+	t.Helper()
+	r, err := gzip.NewReader(bytes.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestBook_JSONGzip(t *testing.T) {
+
+	b := NewBook()
+	if err := b.Parse([]byte(`[{"id":1,"name":"A"}]`)); err != nil {
+		t.Fatal(err)
+	}
+	if b.JSONGzip() != nil {
+		t.Error("expected nil without option WithGzip")
+	}
+
+	reverse := func(src []byte) ([]byte, error) {
+		res := make([]byte, len(src))
+		for i := range src {
+			res[len(src)-1-i] = src[i]
+		}
+		return res, nil
+	}
+
+	b = NewBook(WithGzip(), WithEncoder("rev", reverse))
+	if err := b.Parse([]byte(`[{"id":1,"name":"A"},{"id":2,"name":"B"}]`)); err != nil {
+		t.Fatal(err)
+	}
+
+	if res := gunzip(t, b.JSONGzip()); !bytes.Equal(res, b.JSON()) {
+		t.Errorf("expected %s, got %s", b.JSON(), res)
+	}
+	if res, _ := reverse(b.JSONEncoded("rev")); !bytes.Equal(res, b.JSON()) {
+		t.Errorf("expected %s, got %s", b.JSON(), res)
+	}
+	if b.JSONEncoded("br") != nil {
+		t.Error("expected nil for unknown coding")
+	}
+
+	// recompressed after change.
+	b.Set(3, "C")
+	if err := b.Optimize(); err != nil {
+		t.Fatal(err)
+	}
+	if res := gunzip(t, b.JSONGzip()); !bytes.Equal(res, b.JSON()) {
+		t.Errorf("expected %s, got %s", b.JSON(), res)
+	}
+}
+
+func TestFlexBook_JSONGzip(t *testing.T) {
+
+	b := NewFlexBook(WithDefaultLang("en"), WithGzip())
+	if err := b.Parse([]byte(`[{"id":1,"name":{"en":"A","ru":"AA"}}]`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Optimize(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, lang := range []string{"en", "ru"} {
+		bk := b.Book(lang)
+		if res := gunzip(t, bk.JSONGzip()); !bytes.Equal(res, bk.JSON()) {
+			t.Errorf("%s: expected %s, got %s", lang, bk.JSON(), res)
+		}
+	}
+}
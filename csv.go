@@ -0,0 +1,534 @@
+package refbook
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// csvAliasSep separates aliases in CSV cell.
+	csvAliasSep = "|"
+
+	// csvFormulaChars start cells taken by spreadsheets as formulas.
+	csvFormulaChars = "=+-@\t\r"
+)
+
+// CSVOption holds ParseCSV and WriteCSV configuration.
+type CSVOption struct {
+	comma   rune
+	columns map[string]string // header column by field.
+	err     error             // first invalid option.
+}
+
+// WithCSVColumn maps field to header column, for instance
+// WithCSVColumn("name_en", "Country"). Fields are id, name, name_<lang>,
+// aliases, aliases_<lang>, sort_order, is_active, valid_from and valid_to.
+// Mapping of several fields to one column is an error returned by
+// ParseCSV and WriteCSV.
+// Aliases in a cell are separated by "|".
+func WithCSVColumn(field, column string) func(o *CSVOption) {
+	return func(o *CSVOption) {
+		if o.columns == nil {
+			o.columns = make(map[string]string)
+		}
+		for f, c := range o.columns {
+			if c == column && f != field && o.err == nil {
+				o.err = fmt.Errorf("column %q is mapped to fields %s and %s", column, f, field)
+			}
+		}
+		o.columns[field] = column
+	}
+}
+
+// WithCSVComma replaces default field delimiter ','.
+func WithCSVComma(comma rune) func(o *CSVOption) {
+	return func(o *CSVOption) {
+		o.comma = comma
+	}
+}
+
+func newCSVOption(f []func(*CSVOption)) CSVOption {
+	o := CSVOption{comma: ','}
+	for i := range f {
+		f[i](&o)
+	}
+	return o
+}
+
+// field returns field kept by header column. Column is mapped to one
+// field at most, see WithCSVColumn.
+func (o *CSVOption) field(column string) string {
+	for f, c := range o.columns {
+		if c == column {
+			return f
+		}
+	}
+	return column
+}
+
+// column returns header column keeping field.
+func (o *CSVOption) column(field string) string {
+	if c, ok := o.columns[field]; ok {
+		return c
+	}
+	return field
+}
+
+// langField returns field of language lang, for instance name_en.
+// Empty lang gives field without language.
+func langField(field, lang string) string {
+	if lang == "" {
+		return field
+	}
+	return field + "_" + lang
+}
+
+// csvLayout keeps positions of fields in CSV record, -1 if missing.
+type csvLayout struct {
+	id        int
+	sortOrder int
+	isActive  int
+	validFrom int
+	validTo   int
+	names     map[string]int // by language, empty for field name.
+	aliases   map[string]int
+}
+
+// newCSVLayout recognizes fields of header columns. Unknown columns are
+// ignored.
+func newCSVLayout(header []string, o *CSVOption) (*csvLayout, error) {
+	l := csvLayout{id: -1, sortOrder: -1, isActive: -1, validFrom: -1, validTo: -1,
+		names: map[string]int{}, aliases: map[string]int{}}
+
+	set := func(pos *int, i int) error {
+		if *pos != -1 {
+			return fmt.Errorf("column %q is duplicated", header[i])
+		}
+		*pos = i
+		return nil
+	}
+
+	setLang := func(m map[string]int, lang string, i int) error {
+		if lang != "" && ToLangCode(lang) == 0 {
+			return fmt.Errorf("column %q has unknown language", header[i])
+		}
+		pos, ok := m[lang]
+		if !ok {
+			pos = -1
+		}
+		if err := set(&pos, i); err != nil {
+			return err
+		}
+		m[lang] = pos
+		return nil
+	}
+
+	for i := range header {
+		var err error
+		switch f := o.field(strings.TrimSpace(header[i])); f {
+		case "id":
+			err = set(&l.id, i)
+		case "sort_order":
+			err = set(&l.sortOrder, i)
+		case "is_active":
+			err = set(&l.isActive, i)
+		case "valid_from":
+			err = set(&l.validFrom, i)
+		case "valid_to":
+			err = set(&l.validTo, i)
+		case "name":
+			err = setLang(l.names, "", i)
+		case "aliases":
+			err = setLang(l.aliases, "", i)
+		default:
+			if lang, ok := strings.CutPrefix(f, "name_"); ok {
+				err = setLang(l.names, lang, i)
+			} else if lang, ok := strings.CutPrefix(f, "aliases_"); ok {
+				err = setLang(l.aliases, lang, i)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if l.id == -1 {
+		return nil, errors.New("column id not found")
+	}
+	if len(l.names) == 0 {
+		return nil, errors.New("column name not found")
+	}
+	return &l, nil
+}
+
+// item returns the item of CSV record. Names and aliases are keyed by
+// language, empty for fields without language.
+func (l *csvLayout) item(rec []string) (MultiLangItem, error) {
+	item := MultiLangItem{Name: make(map[string]string, len(l.names))}
+
+	var err error
+	if item.ID, err = strconv.Atoi(strings.TrimSpace(rec[l.id])); err != nil {
+		return item, fmt.Errorf("invalid id %q", rec[l.id])
+	}
+
+	for lang, i := range l.names {
+		item.Name[lang] = unescapeCell(rec[i])
+	}
+	for lang, i := range l.aliases {
+		if rec[i] == "" {
+			continue
+		}
+		if item.Aliases == nil {
+			item.Aliases = make(map[string][]string, len(l.aliases))
+		}
+		item.Aliases[lang] = strings.Split(unescapeCell(rec[i]), csvAliasSep)
+	}
+
+	if v := cell(rec, l.sortOrder); v != "" {
+		if item.SortOrder, err = strconv.Atoi(v); err != nil {
+			return item, fmt.Errorf("invalid sort_order %q", v)
+		}
+	}
+	if v := cell(rec, l.isActive); v != "" {
+		isActive, err := strconv.ParseBool(v)
+		if err != nil {
+			return item, fmt.Errorf("invalid is_active %q", v)
+		}
+		item.Inactive = !isActive
+	}
+	if v := cell(rec, l.validFrom); v != "" {
+		if item.ValidFrom, err = parseTime(v); err != nil {
+			return item, fmt.Errorf("valid_from: %w", err)
+		}
+	}
+	if v := cell(rec, l.validTo); v != "" {
+		if item.ValidTo, err = parseTime(v); err != nil {
+			return item, fmt.Errorf("valid_to: %w", err)
+		}
+	}
+	return item, nil
+}
+
+// cell returns trimmed value of the record at position i, empty if i is -1.
+func cell(rec []string, i int) string {
+	if i == -1 {
+		return ""
+	}
+	return strings.TrimSpace(rec[i])
+}
+
+// readCSV reads items from CSV having header. Returns languages of name
+// columns, empty for column name. Errors refer to lines of r.
+func readCSV(r io.Reader, o *CSVOption) ([]MultiLangItem, []string, error) {
+	if o.err != nil {
+		return nil, nil, o.err
+	}
+
+	cr := csv.NewReader(r)
+	cr.Comma = o.comma
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil, errors.New("header not found")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// spreadsheets add byte order mark.
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+
+	l, err := newCSVLayout(header, o)
+	if err != nil {
+		line, _ := cr.FieldPos(0)
+		return nil, nil, fmt.Errorf("line %d: %w", line, err)
+	}
+
+	var items []MultiLangItem
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		item, err := l.item(rec)
+		if err == nil {
+			err = item.validate()
+		}
+		if err != nil {
+			line, _ := cr.FieldPos(0)
+			return nil, nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		items = append(items, item)
+	}
+
+	langs := make([]string, 0, len(l.names))
+	for lang := range l.names {
+		langs = append(langs, lang)
+	}
+	return items, langs, nil
+}
+
+// ParseCSV replaces items of the book by records of CSV having header
+// with columns id and name, see WithCSVColumn. If column name is missing,
+// column name_<lang> of the book language is taken, see WithDefaultLang. Rows having the same
+// id and validity columns are versions of the item, see ItemOf.
+// Errors refer to lines of r.
+func (b *Book) ParseCSV(r io.Reader, f ...func(*CSVOption)) error {
+	o := newCSVOption(f)
+	mlItems, langs, err := readCSV(r, &o)
+	if err != nil {
+		return err
+	}
+
+	lc := b.langCode()
+
+	// column name is preferred to the column of the book language.
+	lang, ok := "", slices.Contains(langs, "")
+	for i := 0; !ok && i < len(langs); i++ {
+		if ToLangCode(langs[i]) == lc {
+			lang, ok = langs[i], true
+		}
+	}
+	if !ok {
+		return fmt.Errorf("column %s not found", o.column("name"))
+	}
+
+	items := make([]Item, len(mlItems))
+	for i := range mlItems {
+		items[i] = mlItems[i].item(mlItems[i].Name[lang], mlItems[i].Aliases[lang])
+	}
+	return b.load(items)
+}
+
+// ParseCSV replaces items of the book by records of CSV having header
+// with columns id, name_<lang> in each language or column name, see
+// Book.ParseCSV.
+func (b *FlexBook) ParseCSV(r io.Reader, f ...func(*CSVOption)) error {
+	o := newCSVOption(f)
+	mlItems, langs, err := readCSV(r, &o)
+	if err != nil {
+		return err
+	}
+
+	for _, lang := range langs {
+		if lang != "" {
+			continue
+		}
+		if len(langs) > 1 {
+			return errors.New("name column has different types")
+		}
+		items := make([]Item, len(mlItems))
+		for i := range mlItems {
+			items[i] = mlItems[i].item(mlItems[i].Name[""], mlItems[i].Aliases[""])
+		}
+		return b.ReplaceItems(items)
+	}
+	return b.Replace(mlItems)
+}
+
+// WriteCSV writes items with all versions to w as CSV with header
+// id,name. Columns aliases, sort_order, is_active, valid_from and
+// valid_to are written if any item has them. Inactive items are written.
+func (b *Book) WriteCSV(w io.Writer, f ...func(*CSVOption)) error {
+	items := b.snapshot().versions()
+	mlItems := make([]MultiLangItem, len(items))
+	for i := range items {
+		mlItems[i] = multiLangItem(items[i])
+		mlItems[i].set("", items[i])
+	}
+	o := newCSVOption(f)
+	return writeCSV(w, []string{""}, mlItems, &o)
+}
+
+// WriteCSV writes items with all versions to w as CSV with header
+// id,name_<lang> for all languages, see Book.WriteCSV.
+func (b *FlexBook) WriteCSV(w io.Writer, f ...func(*CSVOption)) error {
+	fb := b.books.Load()
+
+	type key struct {
+		id   int
+		from int64
+	}
+	keyOf := func(item *Item) key {
+		k := key{id: item.ID}
+		if !item.ValidFrom.IsZero() {
+			k.from = item.ValidFrom.UnixNano()
+		}
+		return k
+	}
+
+	// language books have the same items, but order can differ.
+	langs := make([]string, len(fb.bi))
+	byKey := make([]map[key]Item, len(fb.bi))
+	for i := range fb.book {
+		langs[i] = fb.bi[i].String()
+		byKey[i] = map[key]Item{}
+		for _, item := range fb.book[i].snapshot().versions() {
+			byKey[i][keyOf(&item)] = item
+		}
+	}
+
+	items := fb.book[0].snapshot().versions()
+	mlItems := make([]MultiLangItem, len(items))
+	for i := range items {
+		mlItems[i] = multiLangItem(items[i])
+		k := keyOf(&items[i])
+		for j := range langs {
+			mlItems[i].set(langs[j], byKey[j][k])
+		}
+	}
+
+	o := newCSVOption(f)
+	return writeCSV(w, langs, mlItems, &o)
+}
+
+// multiLangItem returns multi language item having attributes of the item
+// without names.
+func multiLangItem(item Item) MultiLangItem {
+	return MultiLangItem{
+		ID:        item.ID,
+		Name:      map[string]string{},
+		SortOrder: item.SortOrder,
+		Inactive:  item.Inactive,
+		ValidFrom: item.ValidFrom,
+		ValidTo:   item.ValidTo,
+	}
+}
+
+// set sets name and aliases of the item in language lang.
+func (item *MultiLangItem) set(lang string, src Item) {
+	item.Name[lang] = src.Name
+	if len(src.Aliases) > 0 {
+		if item.Aliases == nil {
+			item.Aliases = map[string][]string{}
+		}
+		item.Aliases[lang] = src.Aliases
+	}
+}
+
+// csvColumn describes column written by WriteCSV.
+type csvColumn struct {
+	field string
+	value func(item *MultiLangItem) string
+}
+
+// writeCSV writes items to w. Names are written in languages langs,
+// empty language gives column name.
+func writeCSV(w io.Writer, langs []string, items []MultiLangItem, o *CSVOption) error {
+	if o.err != nil {
+		return o.err
+	}
+
+	hasAliases := map[string]bool{}
+	var hasSortOrder, hasInactive, hasValidFrom, hasValidTo bool
+	for i := range items {
+		for lang := range items[i].Aliases {
+			hasAliases[lang] = true
+		}
+		hasSortOrder = hasSortOrder || items[i].SortOrder != 0
+		hasInactive = hasInactive || items[i].Inactive
+		hasValidFrom = hasValidFrom || !items[i].ValidFrom.IsZero()
+		hasValidTo = hasValidTo || !items[i].ValidTo.IsZero()
+	}
+
+	cols := []csvColumn{{"id", func(item *MultiLangItem) string {
+		return strconv.Itoa(item.ID)
+	}}}
+	for _, lang := range langs {
+		lang := lang
+		cols = append(cols, csvColumn{langField("name", lang), func(item *MultiLangItem) string {
+			return escapeCell(item.Name[lang])
+		}})
+	}
+	for _, lang := range langs {
+		lang := lang
+		if hasAliases[lang] {
+			cols = append(cols, csvColumn{langField("aliases", lang), func(item *MultiLangItem) string {
+				return escapeCell(strings.Join(item.Aliases[lang], csvAliasSep))
+			}})
+		}
+	}
+	if hasSortOrder {
+		cols = append(cols, csvColumn{"sort_order", func(item *MultiLangItem) string {
+			return strconv.Itoa(item.SortOrder)
+		}})
+	}
+	if hasInactive {
+		cols = append(cols, csvColumn{"is_active", func(item *MultiLangItem) string {
+			return strconv.FormatBool(!item.Inactive)
+		}})
+	}
+	if hasValidFrom {
+		cols = append(cols, csvColumn{"valid_from", func(item *MultiLangItem) string {
+			return formatTime(item.ValidFrom)
+		}})
+	}
+	if hasValidTo {
+		cols = append(cols, csvColumn{"valid_to", func(item *MultiLangItem) string {
+			return formatTime(item.ValidTo)
+		}})
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Comma = o.comma
+
+	rec := make([]string, len(cols))
+	for i := range cols {
+		rec[i] = o.column(cols[i].field)
+	}
+	if err := cw.Write(rec); err != nil {
+		return err
+	}
+
+	for i := range items {
+		for j := range cols {
+			rec[j] = cols[j].value(&items[i])
+		}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// escapeCell prefixes by "'" the cell which spreadsheet would take as
+// formula. Cells starting with "'" are prefixed too, so they are read back
+// unchanged, see unescapeCell.
+func escapeCell(s string) string {
+	if s != "" && strings.IndexByte(csvFormulaChars+"'", s[0]) != -1 {
+		return "'" + s
+	}
+	return s
+}
+
+// unescapeCell removes prefix "'" added by escapeCell.
+func unescapeCell(s string) string {
+	if len(s) > 1 && s[0] == '\'' && strings.IndexByte(csvFormulaChars+"'", s[1]) != -1 {
+		return s[1:]
+	}
+	return s
+}
+
+// formatTime returns time as date "2006-01-02" if it's midnight UTC and
+// as RFC 3339 otherwise, empty for zero time. See parseTime.
+func formatTime(t time.Time) string {
+	switch {
+	case t.IsZero():
+		return ""
+	case t.Truncate(24 * time.Hour).Equal(t):
+		return t.UTC().Format(time.DateOnly)
+	}
+	return t.Format(time.RFC3339Nano)
+}
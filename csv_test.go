@@ -0,0 +1,231 @@
+package refbook
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestBook_ParseCSV(t *testing.T) {
+
+	src := "\ufeffid,name,aliases,is_active,comment\n" +
+		"1,United States,USA|America,,\n" +
+		"2,\"Korea, Republic of\",,true,quoted\n" +
+		"3,Yugoslavia,,false,retired\n"
+
+	b := NewConcurrentBook()
+	if err := b.ParseCSV(strings.NewReader(src)); err != nil {
+		t.Fatal(err)
+	}
+
+	if name := b.Name(0, 2); name != "Korea, Republic of" {
+		t.Errorf("expected Korea, Republic of, got %s", name)
+	}
+	if id, err := b.IDByName(0, "america", MatchCaseInsensitive); err != nil || id != 1 {
+		t.Errorf("expected 1, got %d %v", id, err)
+	}
+	if res := itemIDs(b.ActiveItems()); len(res) != 2 {
+		t.Errorf("expected 2 active items, got %v", res)
+	}
+
+	var buf bytes.Buffer
+	if err := b.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := "id,name,aliases,is_active\n" +
+		"1,United States,USA|America,true\n" +
+		"2,\"Korea, Republic of\",,true\n" +
+		"3,Yugoslavia,,false\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestBook_ParseCSVErrors(t *testing.T) {
+
+	tcs := []struct {
+		src string
+		err string
+	}{
+		{"", "header not found"},
+		{"name\nHello\n", "line 1: column id not found"},
+		{"id,name,name\n1,Hello,Hello\n", `line 1: column "name" is duplicated`},
+		{"id,name\n1,Hello\nx,World\n", `line 3: invalid id "x"`},
+		{"id,name,is_active\n1,Hello,yes\n", `line 2: invalid is_active "yes"`},
+		{"id,name,valid_from\n\n1,Hello,2019-13-01\n", `line 3: valid_from: invalid time "2019-13-01"`},
+		{"id,name,valid_from,valid_to\n1,Hello,2020-01-01,2019-01-01\n", "line 2: valid_to is not after valid_from"},
+		{"id,name\n1,\"Hello\n", "parse error on line 2"},
+	}
+
+	for _, tc := range tcs {
+		err := NewBook().ParseCSV(strings.NewReader(tc.src))
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%q: expected error %q, got %v", tc.src, tc.err, err)
+		}
+	}
+}
+
+func TestBook_ParseCSVLang(t *testing.T) {
+
+	src := "id,name_en,name_ru\n1,Germany,Германия\n"
+
+	b := NewBook(WithDefaultLang("ru"))
+	if err := b.ParseCSV(strings.NewReader(src)); err != nil {
+		t.Fatal(err)
+	}
+	if name := b.Name(0, 1); name != "Германия" {
+		t.Errorf("expected Германия, got %s", name)
+	}
+
+	if err := NewBook(WithDefaultLang("de")).ParseCSV(strings.NewReader(src)); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestBook_ParseCSVColumns(t *testing.T) {
+
+	src := "Code;Country;From\n1;Deutschland;2019-01-01\n"
+
+	opts := []func(*CSVOption){
+		WithCSVComma(';'),
+		WithCSVColumn("id", "Code"),
+		WithCSVColumn("name", "Country"),
+		WithCSVColumn("valid_from", "From"),
+	}
+
+	b := NewBook()
+	if err := b.ParseCSV(strings.NewReader(src), opts...); err != nil {
+		t.Fatal(err)
+	}
+	if name := b.Name(0, 1); name != "Deutschland" {
+		t.Errorf("expected Deutschland, got %s", name)
+	}
+
+	var buf bytes.Buffer
+	if err := b.WriteCSV(&buf, opts...); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != src {
+		t.Errorf("expected %q, got %q", src, buf.String())
+	}
+}
+
+func TestFlexBook_ParseCSV(t *testing.T) {
+
+	src := "id,name_en,name_ru,aliases_en,valid_from,valid_to\n" +
+		"1,Old,Старый,,,2019-01-01\n" +
+		"1,New,Новый,Brand new,2019-01-01,\n" +
+		"2,World,Мир,Earth|Globe,,\n"
+
+	b := NewFlexBook()
+	if err := b.ParseCSV(strings.NewReader(src)); err != nil {
+		t.Fatal(err)
+	}
+
+	ru := ToLangCode("ru")
+	if name := b.Name(ru, 2); name != "Мир" {
+		t.Errorf("expected Мир, got %s", name)
+	}
+	if name := b.NameAt(ru, 1, date(2018, 1, 1)); name != "Старый" {
+		t.Errorf("expected Старый, got %s", name)
+	}
+	if id, err := b.IDByName(ToLangCode("en"), "Globe", MatchExact); err != nil || id != 2 {
+		t.Errorf("expected 2, got %d %v", id, err)
+	}
+
+	var buf bytes.Buffer
+	if err := b.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != src {
+		t.Errorf("expected %q, got %q", src, buf.String())
+	}
+
+	// the book in default language is taken from multi language CSV.
+	bk := NewBook()
+	if err := bk.ParseCSV(strings.NewReader(src)); err != nil {
+		t.Fatal(err)
+	}
+	if name := bk.Name(0, 2); name != "World" {
+		t.Errorf("expected World, got %s", name)
+	}
+
+	if err := b.ParseCSV(strings.NewReader("id,name,name_en\n1,Hello,Hello\n")); err == nil {
+		t.Error("expected error for mixed name columns")
+	}
+	if err := b.ParseCSV(strings.NewReader("id,name_en\n1,Hello\n2,\n")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBook_CSVDuplicatedColumn(t *testing.T) {
+
+	opts := []func(*CSVOption){
+		WithCSVColumn("name", "Country"),
+		WithCSVColumn("name_en", "Country"),
+	}
+	expected := `column "Country" is mapped to fields name and name_en`
+
+	err := NewBook().ParseCSV(strings.NewReader("id,Country\n1,Germany\n"), opts...)
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected error %q, got %v", expected, err)
+	}
+	if err := NewBook().WriteCSV(io.Discard, opts...); err == nil || err.Error() != expected {
+		t.Errorf("expected error %q, got %v", expected, err)
+	}
+
+	// field mapped again is remapped.
+	opts = []func(*CSVOption){WithCSVColumn("name", "Country"), WithCSVColumn("name", "Land")}
+	if err := NewBook().ParseCSV(strings.NewReader("id,Land\n1,Germany\n"), opts...); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBook_CSVFormula(t *testing.T) {
+
+	b := NewBook()
+	err := b.Replace([]Item{
+		{ID: 1, Name: "=HYPERLINK(\"http://example.com\")"},
+		{ID: 2, Name: "-5%", Aliases: []string{"@minus"}},
+		{ID: 3, Name: "'quoted"},
+		{ID: 4, Name: "plain"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := b.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := "id,name,aliases\n" +
+		"1,\"'=HYPERLINK(\"\"http://example.com\"\")\",\n" +
+		"2,'-5%,'@minus\n" +
+		"3,''quoted,\n" +
+		"4,plain,\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+
+	c := NewBook()
+	if err := c.ParseCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range b.Items() {
+		if name := c.Name(0, item.ID); name != item.Name {
+			t.Errorf("expected %s, got %s", item.Name, name)
+		}
+	}
+	if id, err := c.IDByName(0, "@minus", MatchExact); err != nil || id != 2 {
+		t.Errorf("expected 2, got %d %v", id, err)
+	}
+
+	// apostrophe not followed by formula character is kept.
+	if err := c.ParseCSV(strings.NewReader("id,name\n1,'tis\n")); err != nil {
+		t.Fatal(err)
+	}
+	if name := c.Name(0, 1); name != "'tis" {
+		t.Errorf("expected 'tis, got %s", name)
+	}
+}
@@ -0,0 +1,188 @@
+package refbook
+
+import "sync"
+
+// DeltaOf describes changes of the book since the version known by client.
+type DeltaOf[K comparable, V any] struct {
+	Hash         uint64         `json:"hash,string"` // hash of the current version.
+	IsFullReload bool           `json:"full_reload,omitempty"`
+	Added        []ItemOf[K, V] `json:"added,omitempty"`
+	Changed      []ItemOf[K, V] `json:"changed,omitempty"`
+	Deleted      []K            `json:"deleted,omitempty"`
+}
+
+// Delta describes changes of Book.
+type Delta = DeltaOf[int, string]
+
+// WithHistory informs that the book keeps depth last optimized versions
+// used by Delta. FlexBook keeps depth versions of each language.
+func WithHistory(depth int) func(o *Option) {
+	return func(o *Option) {
+		o.historyDepth = depth
+	}
+}
+
+// Delta returns changes of the book since the version having hash
+// sinceHash, for instance the hash of the book cached by client.
+// IsFullReload is set if the version is not kept by the history, see
+// WithHistory, or the book is not optimized.
+func (b *BookOf[K, V]) Delta(sinceHash uint64) DeltaOf[K, V] {
+	s := b.compiled()
+	d := DeltaOf[K, V]{Hash: s.jsonInput.Hash}
+	if s.isCompileRequired {
+		d.IsFullReload = true
+		return d
+	}
+	if sinceHash == d.Hash {
+		return d
+	}
+
+	v := b.history.version(sinceHash)
+	if v == nil {
+		d.IsFullReload = true
+		return d
+	}
+	v.delta(b.jsonItems(s.jsonInput.Items), &d)
+	return d
+}
+
+// Delta returns changes of the book in language lang since the version
+// having hash sinceHash, see Book.Delta.
+func (b *FlexBook) Delta(lang string, sinceHash uint64) Delta {
+	fb := b.compiled()
+	return fb.book[fb.languageIndex(lang, &b.langs)].Delta(sinceHash)
+}
+
+// history keeps last versions of the book.
+type history[K comparable, V any] struct {
+	mux      sync.Mutex
+	depth    int
+	versions []*version[K, V] // from the oldest to the newest.
+}
+
+// version is a copy of items of the optimized snapshot.
+type version[K comparable, V any] struct {
+	hash  uint64
+	m     map[K]int // index in items by id.
+	items []ItemOf[K, V]
+}
+
+// newHistory returns nil if depth is not positive, nil history keeps
+// nothing.
+func newHistory[K comparable, V any](depth int) *history[K, V] {
+	if depth <= 0 {
+		return nil
+	}
+	return &history[K, V]{depth: depth}
+}
+
+// add records items of optimized snapshot having hash as the newest
+// version. Items are copied because snapshot of not concurrent book is
+// modified in place.
+func (h *history[K, V]) add(hash uint64, items []ItemOf[K, V]) {
+	if h == nil {
+		return
+	}
+
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	v := h.remove(hash)
+	if v == nil {
+		v = &version[K, V]{
+			hash:  hash,
+			m:     make(map[K]int, len(items)),
+			items: append([]ItemOf[K, V](nil), items...),
+		}
+		for i := range v.items {
+			v.m[v.items[i].ID] = i
+		}
+	}
+
+	if len(h.versions) == h.depth {
+		h.versions = append(h.versions[:0], h.versions[1:]...)
+	}
+	h.versions = append(h.versions, v)
+}
+
+// remove removes the version by hash. Returns nil if not found.
+// Requires h.mux to be locked.
+func (h *history[K, V]) remove(hash uint64) *version[K, V] {
+	for i, v := range h.versions {
+		if v.hash == hash {
+			h.versions = append(h.versions[:i], h.versions[i+1:]...)
+			return v
+		}
+	}
+	return nil
+}
+
+// version returns the version by hash. Returns nil if not found.
+func (h *history[K, V]) version(hash uint64) *version[K, V] {
+	if h == nil {
+		return nil
+	}
+
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	for _, v := range h.versions {
+		if v.hash == hash {
+			return v
+		}
+	}
+	return nil
+}
+
+// delta adds to d changes turning v into items.
+func (v *version[K, V]) delta(items []ItemOf[K, V], d *DeltaOf[K, V]) {
+	ids := make(map[K]struct{}, len(items))
+	for _, item := range items {
+		ids[item.ID] = struct{}{}
+		i, ok := v.m[item.ID]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, item)
+		case !equalItems(v.items[i], item):
+			d.Changed = append(d.Changed, item)
+		}
+	}
+	for _, item := range v.items {
+		if _, ok := ids[item.ID]; !ok {
+			d.Deleted = append(d.Deleted, item.ID)
+		}
+	}
+}
+
+// langHistory keeps histories of language books of FlexBook. It outlives
+// language books replaced by reload.
+type langHistory struct {
+	mux   sync.Mutex
+	depth int
+	m     map[LangCode]*history[int, string]
+}
+
+// newLangHistory returns nil if depth is not positive.
+func newLangHistory(depth int) *langHistory {
+	if depth <= 0 {
+		return nil
+	}
+	return &langHistory{depth: depth, m: make(map[LangCode]*history[int, string])}
+}
+
+// lang returns history of the language book.
+func (lh *langHistory) lang(lc LangCode) *history[int, string] {
+	if lh == nil {
+		return nil
+	}
+
+	lh.mux.Lock()
+	defer lh.mux.Unlock()
+
+	h, ok := lh.m[lc]
+	if !ok {
+		h = newHistory[int, string](lh.depth)
+		lh.m[lc] = h
+	}
+	return h
+}
@@ -0,0 +1,160 @@
+package refbook
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestBook_Delta(t *testing.T) {
+
+	b := NewConcurrentBook(WithHistory(2))
+	if err := b.Parse([]byte(`[{"id":1,"name":"A"},{"id":2,"name":"B"},{"id":3,"name":"C"}]`)); err != nil {
+		t.Fatal(err)
+	}
+	h1 := b.Hash()
+
+	if d := b.Delta(h1); d.IsFullReload || d.Hash != h1 || d.Added != nil || d.Changed != nil || d.Deleted != nil {
+		t.Errorf("expected empty delta, got %+v", d)
+	}
+
+	b.Set(2, "BB")
+	b.Set(4, "D")
+	b.Delete(1)
+	if d := b.Delta(h1); !d.IsFullReload {
+		t.Errorf("expected full reload of not optimized book, got %+v", d)
+	}
+	if err := b.Optimize(); err != nil {
+		t.Fatal(err)
+	}
+	h2 := b.Hash()
+
+	expected := Delta{
+		Hash:    h2,
+		Added:   []Item{{ID: 4, Name: "D"}},
+		Changed: []Item{{ID: 2, Name: "BB"}},
+		Deleted: []int{1},
+	}
+	if d := b.Delta(h1); !reflect.DeepEqual(d, expected) {
+		t.Errorf("expected %+v, got %+v", expected, d)
+	}
+
+	if d := b.Delta(12345); !d.IsFullReload || d.Hash != h2 {
+		t.Errorf("expected full reload, got %+v", d)
+	}
+
+	// the oldest version is dropped.
+	b.Set(5, "E")
+	if err := b.Optimize(); err != nil {
+		t.Fatal(err)
+	}
+	if d := b.Delta(h1); !d.IsFullReload {
+		t.Errorf("expected full reload, got %+v", d)
+	}
+	if d := b.Delta(h2); d.IsFullReload || !reflect.DeepEqual(d.Added, []Item{{ID: 5, Name: "E"}}) {
+		t.Errorf("unexpected delta %+v", d)
+	}
+
+	// no history.
+	b = NewBook()
+	if err := b.Parse([]byte(`[{"id":1,"name":"A"}]`)); err != nil {
+		t.Fatal(err)
+	}
+	h := b.Hash()
+	b.Set(2, "B")
+	if err := b.Optimize(); err != nil {
+		t.Fatal(err)
+	}
+	if d := b.Delta(h); !d.IsFullReload {
+		t.Errorf("expected full reload, got %+v", d)
+	}
+}
+
+func TestFlexBook_Delta(t *testing.T) {
+
+	b := NewFlexBook(WithThreadSafe(), WithDefaultLang("en"), WithHistory(4))
+	err := b.Replace([]MultiLangItem{
+		{ID: 1, Name: map[string]string{"en": "A", "ru": "AA"}},
+		{ID: 2, Name: map[string]string{"en": "B", "ru": "BB"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hen, hru := b.Hash("en"), b.Hash("ru")
+
+	// history survives reload of all items.
+	err = b.Replace([]MultiLangItem{
+		{ID: 1, Name: map[string]string{"en": "A", "ru": "AAA"}},
+		{ID: 3, Name: map[string]string{"en": "C", "ru": "CC"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Delta{
+		Hash:    b.Hash("ru"),
+		Added:   []Item{{ID: 3, Name: "CC"}},
+		Changed: []Item{{ID: 1, Name: "AAA"}},
+		Deleted: []int{2},
+	}
+	if d := b.Delta("ru", hru); !reflect.DeepEqual(d, expected) {
+		t.Errorf("expected %+v, got %+v", expected, d)
+	}
+
+	d := b.Delta("en", hen)
+	if d.IsFullReload || !reflect.DeepEqual(d.Added, []Item{{ID: 3, Name: "C"}}) || d.Changed != nil {
+		t.Errorf("unexpected delta %+v", d)
+	}
+}
+
+func TestBook_DeltaInactive(t *testing.T) {
+
+	b := NewConcurrentBook(WithHistory(2))
+	if err := b.Parse([]byte(`[{"id":1,"name":"A"},{"id":2,"name":"B","is_active":false},{"id":3,"name":"C"}]`)); err != nil {
+		t.Fatal(err)
+	}
+	h := b.Hash()
+	var old struct{ Items []Item }
+	if err := json.Unmarshal(b.JSON(), &old); err != nil {
+		t.Fatal(err)
+	}
+
+	b.SetActive(1, false)
+	b.SetActive(2, true)
+	b.Set(4, "D")
+	if err := b.Optimize(); err != nil {
+		t.Fatal(err)
+	}
+
+	// delta applied to JSON of the old version gives JSON of the new one.
+	d := b.Delta(h)
+	if d.IsFullReload {
+		t.Fatalf("unexpected full reload")
+	}
+	m := make(map[int]Item)
+	for _, item := range old.Items {
+		m[item.ID] = item
+	}
+	for _, item := range append(d.Added, d.Changed...) {
+		m[item.ID] = item
+	}
+	for _, id := range d.Deleted {
+		delete(m, id)
+	}
+
+	var cur struct{ Items []Item }
+	if err := json.Unmarshal(b.JSON(), &cur); err != nil {
+		t.Fatal(err)
+	}
+	if len(m) != len(cur.Items) {
+		t.Errorf("expected %d items, got %v", len(cur.Items), m)
+	}
+	for _, item := range cur.Items {
+		if !equalItems(m[item.ID], item) {
+			t.Errorf("expected %+v, got %+v", item, m[item.ID])
+		}
+	}
+	if !reflect.DeepEqual(d.Deleted, []int{1}) {
+		t.Errorf("expected inactive item is deleted, got %+v", d)
+	}
+}
@@ -0,0 +1,117 @@
+package refbook
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// EventType describes kind of the book item change.
+type EventType int
+
+const (
+	EventAdded EventType = iota + 1
+	EventUpdated
+	EventDeleted
+	EventActivated // item is activated by SetActive, names are not changed.
+	EventRetired   // item is retired by SetActive, names are not changed.
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventAdded:
+		return "added"
+	case EventUpdated:
+		return "updated"
+	case EventDeleted:
+		return "deleted"
+	case EventActivated:
+		return "activated"
+	case EventRetired:
+		return "retired"
+	}
+	return "unknown"
+}
+
+// EventOf describes change of the book item. OldName is empty if the item
+// has been added, NewName is empty if the item has been deleted.
+// Lang is zero for the single language book.
+type EventOf[K comparable] struct {
+	Type    EventType
+	Lang    LangCode
+	ID      K
+	OldName string
+	NewName string
+}
+
+// Event describes change of the Book or FlexBook item.
+type Event = EventOf[int]
+
+// dispatcher delivers events to subscribers in order they have been
+// pushed. Writers push events holding the book lock and flush them after
+// the lock is released, so subscribers are free to access the book.
+type dispatcher[E any] struct {
+	active atomic.Bool // true if there are subscribers.
+	mux    sync.Mutex
+	subs   []func(E)
+	queue  []E
+	busy   bool // events are being delivered.
+}
+
+func (d *dispatcher[E]) subscribe(f func(E)) {
+	d.mux.Lock()
+	d.subs = append(d.subs, f)
+	d.mux.Unlock()
+	d.active.Store(true)
+}
+
+// push queues the event. Does nothing if there are no subscribers.
+func (d *dispatcher[E]) push(e E) {
+	if d == nil || !d.active.Load() {
+		return
+	}
+	d.mux.Lock()
+	d.queue = append(d.queue, e)
+	d.mux.Unlock()
+}
+
+// flush delivers queued events. If events are being delivered by another
+// goroutine, or by the subscriber which has changed the book, flush
+// returns at once and the events are delivered by the current deliverer.
+func (d *dispatcher[E]) flush() {
+	if d == nil || !d.active.Load() {
+		return
+	}
+
+	d.mux.Lock()
+	if d.busy {
+		d.mux.Unlock()
+		return
+	}
+	d.busy = true
+
+	// reset even if a subscriber panics, otherwise events are never
+	// delivered again.
+	defer func() {
+		d.busy = false
+		d.mux.Unlock()
+	}()
+
+	for len(d.queue) > 0 {
+		queue, subs := d.queue, d.subs
+		d.queue = nil
+		d.deliver(queue, subs)
+	}
+}
+
+// deliver calls subscribers with events. Requires d.mux to be locked, it's
+// released during the calls and locked again on return or panic.
+func (d *dispatcher[E]) deliver(queue []E, subs []func(E)) {
+	d.mux.Unlock()
+	defer d.mux.Lock()
+
+	for _, e := range queue {
+		for _, f := range subs {
+			f(e)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package refbook
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestBook_Subscribe(t *testing.T) {
+
+	b := NewConcurrentBook()
+	b.Set(1, "A")
+
+	var evs []Event
+	b.Subscribe(func(e Event) {
+		evs = append(evs, e)
+		// subscriber can modify the book, the change is delivered later.
+		if e.ID == 2 && e.Type == EventAdded {
+			b.Set(3, b.Name(0, 2)+"C")
+		}
+	})
+
+	b.Set(1, "A")
+	b.Set(1, "AA")
+	b.Set(2, "B")
+	b.Delete(1)
+	b.Delete(1)
+	if err := b.Parse([]byte(`[{"id":2,"name":"B"},{"id":3,"name":"CC"},{"id":4,"name":"D"}]`)); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Event{
+		{Type: EventUpdated, ID: 1, OldName: "A", NewName: "AA"},
+		{Type: EventAdded, ID: 2, NewName: "B"},
+		{Type: EventAdded, ID: 3, NewName: "BC"},
+		{Type: EventDeleted, ID: 1, OldName: "AA"},
+		{Type: EventUpdated, ID: 3, OldName: "BC", NewName: "CC"},
+		{Type: EventAdded, ID: 4, NewName: "D"},
+	}
+	if !reflect.DeepEqual(evs, expected) {
+		t.Errorf("expected %v, got %v", expected, evs)
+	}
+}
+
+func TestBook_SubscribeConcurrent(t *testing.T) {
+
+	b := NewConcurrentBook()
+
+	// events of each item come in order of changes.
+	var (
+		mux  sync.Mutex
+		last = map[int]int{}
+		err  string
+	)
+	b.Subscribe(func(e Event) {
+		mux.Lock()
+		defer mux.Unlock()
+		n, _ := strconv.Atoi(e.NewName)
+		if n <= last[e.ID] && err == "" {
+			err = e.NewName + " after " + strconv.Itoa(last[e.ID])
+		}
+		last[e.ID] = n
+		_ = b.Name(0, e.ID)
+	})
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			for i := 1; i <= 100; i++ {
+				b.Set(id, strconv.Itoa(i))
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if err != "" {
+		t.Error(err)
+	}
+	for w := 0; w < 4; w++ {
+		if last[w] != 100 {
+			t.Errorf("expected 100 events of item %d, got %d", w, last[w])
+		}
+	}
+}
+
+func TestFlexBook_Subscribe(t *testing.T) {
+
+	b := NewFlexBook(WithThreadSafe(), WithDefaultLang("en"))
+	b.AddMultiLangItem(MultiLangItem{ID: 1, Name: map[string]string{"en": "A", "ru": "AA"}})
+
+	var evs []Event
+	b.Subscribe(func(e Event) {
+		evs = append(evs, e)
+	})
+
+	en, ru := ToLangCode("en"), ToLangCode("ru")
+	sorted := func() []Event {
+		res := evs
+		evs = nil
+		sort.Slice(res, func(i, j int) bool {
+			if res[i].ID != res[j].ID {
+				return res[i].ID < res[j].ID
+			}
+			return res[i].Lang < res[j].Lang
+		})
+		return res
+	}
+
+	tc := []struct {
+		name     string
+		f        func()
+		expected []Event
+	}{
+		{"add", func() {
+			b.AddMultiLangItem(MultiLangItem{ID: 2, Name: map[string]string{"en": "B", "ru": "BB"}})
+		}, []Event{
+			{Type: EventAdded, Lang: en, ID: 2, NewName: "B"},
+			{Type: EventAdded, Lang: ru, ID: 2, NewName: "BB"},
+		}},
+		{"rename", func() {
+			b.AddMultiLangItem(MultiLangItem{ID: 2, Name: map[string]string{"en": "B", "ru": "Б"}})
+		}, []Event{
+			{Type: EventUpdated, Lang: ru, ID: 2, OldName: "BB", NewName: "Б"},
+		}},
+		{"delete", func() {
+			b.Delete(1)
+		}, []Event{
+			{Type: EventDeleted, Lang: en, ID: 1, OldName: "A"},
+			{Type: EventDeleted, Lang: ru, ID: 1, OldName: "AA"},
+		}},
+		{"replace", func() {
+			_ = b.Replace([]MultiLangItem{{ID: 2, Name: map[string]string{"en": "BBB"}}, {ID: 3, Name: map[string]string{"en": "C"}}})
+		}, []Event{
+			{Type: EventUpdated, Lang: en, ID: 2, OldName: "B", NewName: "BBB"},
+			{Type: EventDeleted, Lang: ru, ID: 2, OldName: "Б"},
+			{Type: EventAdded, Lang: en, ID: 3, NewName: "C"},
+		}},
+	}
+
+	for i := range tc {
+		t.Run(tc[i].name, func(t *testing.T) {
+			tc[i].f()
+			if res := sorted(); !reflect.DeepEqual(res, tc[i].expected) {
+				t.Errorf("expected %v, got %v", tc[i].expected, res)
+			}
+		})
+	}
+}
+
+func TestBook_SubscribeSetActive(t *testing.T) {
+
+	b := NewConcurrentBook()
+	b.Set(1, "A")
+
+	var evs []Event
+	b.Subscribe(func(e Event) {
+		evs = append(evs, e)
+	})
+	b.SetActive(1, false)
+	b.SetActive(1, false)
+	b.SetActive(1, true)
+
+	expected := []Event{
+		{Type: EventRetired, ID: 1, OldName: "A", NewName: "A"},
+		{Type: EventActivated, ID: 1, OldName: "A", NewName: "A"},
+	}
+	if !reflect.DeepEqual(evs, expected) {
+		t.Errorf("expected %v, got %v", expected, evs)
+	}
+}
+
+func TestBook_SubscribePanic(t *testing.T) {
+
+	b := NewConcurrentBook()
+
+	var evs []Event
+	b.Subscribe(func(e Event) {
+		if e.ID == 1 {
+			panic("subscriber failed")
+		}
+		evs = append(evs, e)
+	})
+
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("expected panic")
+			}
+		}()
+		b.Set(1, "A")
+	}()
+
+	// events are delivered after the panic.
+	b.Set(2, "B")
+	if len(evs) != 1 || evs[0].ID != 2 {
+		t.Errorf("unexpected events %v", evs)
+	}
+}
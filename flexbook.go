@@ -7,25 +7,159 @@ import (
 	"reflect"
 	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/mitchellh/hashstructure"
 	"github.com/tidwall/gjson"
 )
 
 // FlexBook implements reference book in-memory storage.
+//
+// Language books of concurrent FlexBook are never modified after they
+// have been published. Writers build modified copies and swap them in.
 type FlexBook struct {
 	defaultLangCode LangCode
+	langs           fallbacks
 	isConcurrent    bool
-	mux             sync.RWMutex
-	bi              []LangCode // index in b of language hash
-	book            []*Book
+	mux             sync.Mutex // serializes writers of concurrent book.
+	books           atomic.Pointer[flexBooks]
 	tableName       string
+	events          dispatcher[Event]
+	lo              *langOption
+
+	isAutoOptimize bool
+	debounce       time.Duration
+	timer          *time.Timer // postponed optimization, guarded by mux.
+}
+
+// langOption holds configuration of language books of FlexBook.
+type langOption struct {
+	encoders     []encoder
+	history      *langHistory
+	order        Order
+	withInactive bool
+}
+
+// flexBooks holds language books of FlexBook.
+type flexBooks struct {
+	bi   []LangCode // index in b of language hash
+	book []*Book
+	lo   *langOption
+}
+
+func newFlexBooks(lc LangCode, lo *langOption) *flexBooks {
+	fb := flexBooks{bi: []LangCode{lc}, lo: lo}
+	fb.book = []*Book{fb.newBook(lc)}
+	return &fb
+}
+
+// newBook returns new book in language lc.
+func (fb *flexBooks) newBook(lc LangCode) *Book {
+	b := NewBook()
+	b.encoders = fb.lo.encoders
+	b.history = fb.lo.history.lang(lc)
+	b.sorter = newSorter(fb.lo.order, lc)
+	b.withInactive = fb.lo.withInactive
+	b.isReadOnly = true
+	b.lang = lc
+	return b
+}
+
+// clone returns deep copy of language books.
+func (fb *flexBooks) clone() *flexBooks {
+	c := flexBooks{
+		bi:   append([]LangCode(nil), fb.bi...),
+		book: make([]*Book, len(fb.book)),
+		lo:   fb.lo,
+	}
+	for i := range fb.book {
+		c.book[i] = fb.book[i].emptyCopy()
+		c.book[i].snap.Store(fb.book[i].snapshot().clone())
+	}
+	return &c
+}
+
+// sort orders items of language books, see WithOrder.
+func (fb *flexBooks) sort() {
+	for i := range fb.book {
+		fb.book[i].snapshot().sort(fb.book[i].sorter)
+	}
+}
+
+// fallbacks keeps language fallback chains of the book.
+type fallbacks struct {
+	def    []LangCode              // default language only.
+	chains map[LangCode][]LangCode // chains end with default language.
 }
 
-// Option holds FlexBook configuration.
+func newFallbacks(def LangCode, chains [][]string) fallbacks {
+	l := fallbacks{def: []LangCode{def}}
+	for _, c := range chains {
+		lc := ToLangCode(c[0])
+		if lc == 0 {
+			continue
+		}
+		if l.chains == nil {
+			l.chains = make(map[LangCode][]LangCode)
+		}
+
+		chain := make([]LangCode, 0, len(c))
+		for _, lang := range c[1:] {
+			if fl := ToLangCode(lang); fl != 0 && fl != lc && !containsLangCode(chain, fl) {
+				chain = append(chain, fl)
+			}
+		}
+		if def != lc && !containsLangCode(chain, def) {
+			chain = append(chain, def)
+		}
+		l.chains[lc] = chain
+	}
+	return l
+}
+
+func containsLangCode(lcs []LangCode, lc LangCode) bool {
+	for i := range lcs {
+		if lcs[i] == lc {
+			return true
+		}
+	}
+	return false
+}
+
+// chain returns languages to be used if lc and its base language are
+// missing, in order of preference. The last one is the default language.
+// Chain of the base language is used if lc has no own chain.
+func (l *fallbacks) chain(lc LangCode) []LangCode {
+	if c, ok := l.chains[lc]; ok {
+		return c
+	}
+	if c, ok := l.chains[lc.Base()]; ok {
+		return c
+	}
+	return l.def
+}
+
+// Option holds FlexBook and Book configuration.
 type Option struct {
-	lang         string
-	isConcurrent bool
-	tableName    string
+	lang           string
+	fallbacks      [][]string
+	isConcurrent   bool
+	tableName      string
+	isAutoOptimize bool
+	debounce       time.Duration
+	encoders       []encoder
+	historyDepth   int
+	order          Order
+	withInactive   bool
+}
+
+func newOption(f []func(*Option)) Option {
+	o := Option{}
+	for i := range f {
+		f[i](&o)
+	}
+	return o
 }
 
 // WithDefaultLang replaces global default language.
@@ -35,6 +169,15 @@ func WithDefaultLang(lang string) func(o *Option) {
 	}
 }
 
+// WithLangFallback sets languages used in order if the name in lang is
+// missing, for instance WithLangFallback("kk", "ru", "en"). Default
+// language is the last resort for all languages.
+func WithLangFallback(lang string, fallback ...string) func(o *Option) {
+	return func(o *Option) {
+		o.fallbacks = append(o.fallbacks, append([]string{lang}, fallback...))
+	}
+}
+
 // WithThreadSafe informs that it is changeable book.
 func WithThreadSafe() func(o *Option) {
 	return func(o *Option) {
@@ -47,36 +190,59 @@ func WithTablename(tableName string) func(o *Option) {
 	}
 }
 
+// WithAutoOptimize informs that hash and JSON are recalculated
+// automatically. They are recompiled lazily on the first read after
+// a change. If debounce is positive, concurrent book is recompiled in
+// background when changes stop coming for debounce duration, so a burst of
+// changes triggers only one recompilation.
+func WithAutoOptimize(debounce time.Duration) func(o *Option) {
+	return func(o *Option) {
+		o.isAutoOptimize = true
+		o.debounce = debounce
+	}
+}
+
 // NewFlexBook returns new reference book instance.
 func NewFlexBook(f ...func(*Option)) *FlexBook {
 	mux.RLock()
 	lc := defaultLangCode
 	mux.RUnlock()
 
-	b := FlexBook{
-		defaultLangCode: lc,
-		bi:              []LangCode{lc},
-		book:            []*Book{NewBook()},
-	}
-
-	o := Option{}
-	for i := range f {
-		f[i](&o)
-	}
-
+	o := newOption(f)
 	if o.lang != "" {
-		lc := ToLangCode(o.lang)
-		b.defaultLangCode = lc
-		b.bi[0] = lc
+		lc = ToLangCode(o.lang)
 	}
-	b.tableName = o.tableName
 
-	if o.isConcurrent {
-		b.isConcurrent = o.isConcurrent
-	}
+	b := FlexBook{
+		defaultLangCode: lc,
+		langs:           newFallbacks(lc, o.fallbacks),
+		tableName:       o.tableName,
+		isConcurrent:    o.isConcurrent,
+		isAutoOptimize:  o.isAutoOptimize,
+		debounce:        o.debounce,
+		lo: &langOption{
+			encoders:     o.encoders,
+			history:      newLangHistory(o.historyDepth),
+			order:        o.order,
+			withInactive: o.withInactive,
+		},
+	}
+	b.books.Store(newFlexBooks(lc, b.lo))
 	return &b
 }
 
+// emptyCopy returns empty book with the same configuration.
+func (b *FlexBook) emptyCopy() *FlexBook {
+	c := FlexBook{
+		defaultLangCode: b.defaultLangCode,
+		langs:           b.langs,
+		tableName:       b.tableName,
+		lo:              b.lo,
+	}
+	c.books.Store(newFlexBooks(b.defaultLangCode, b.lo))
+	return &c
+}
+
 // SetThreadSafe sets flag what wraps access to internals by mutex.
 func (b *FlexBook) SetThreadSafe() {
 	b.isConcurrent = true
@@ -86,150 +252,248 @@ func (b *FlexBook) TableName() string {
 	return b.tableName
 }
 
-// Book returns pointer to the reference book associated with lang.
-// Returns pointer to the book associates with default language if
-// lang not found.
-func (b *FlexBook) Book(lang string) *Book {
-	idx := b.languageIndex(lang)
-	if b.isConcurrent {
-		b.mux.RLock()
+// mutate calls f with language books f can modify. Concurrent book
+// passes to f a copy of the current books and publishes it if f
+// succeeds. Events pushed by f are delivered after the lock is released.
+func (b *FlexBook) mutate(f func(fb *flexBooks) error) error {
+	defer b.events.flush()
+
+	if !b.isConcurrent {
+		fb := b.books.Load()
+		err := f(fb)
+		fb.sort()
+		return err
 	}
-	res := b.book[idx]
-	if b.isConcurrent {
-		b.mux.RUnlock()
+
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	fb := b.books.Load().clone()
+	if err := f(fb); err != nil {
+		return err
+	}
+	fb.sort()
+	b.books.Store(fb)
+	b.scheduleOptimize()
+	return nil
+}
+
+// Subscribe adds function called on every change of the item in any
+// language. Events are delivered in order of changes, after the book lock
+// has been released, so f can access the book. Change made by f is
+// delivered after f returns.
+func (b *FlexBook) Subscribe(f func(Event)) {
+	b.events.subscribe(f)
+}
+
+// scheduleOptimize postpones optimization of concurrent book until
+// changes stop coming for debounce duration. Requires b.mux to be locked.
+func (b *FlexBook) scheduleOptimize() {
+	if !b.isAutoOptimize || b.debounce <= 0 {
+		return
 	}
-	return res
+
+	if b.timer == nil {
+		b.timer = time.AfterFunc(b.debounce, func() {
+			_ = b.optimizeIfRequired(false)
+		})
+		return
+	}
+	b.timer.Reset(b.debounce)
 }
 
-func (b *FlexBook) languageIndex(lang string) int {
-	lc := ToLangCode(lang)
+// compiled returns current language books. Auto optimized book
+// recompiles them if they have been changed.
+func (b *FlexBook) compiled() *flexBooks {
+	fb := b.books.Load()
+	if b.isAutoOptimize && fb.isCompileRequired() {
+		fb, _ = b.recompile(false)
+	}
+	return fb
+}
+
+func (fb *flexBooks) isCompileRequired() bool {
+	for i := range fb.book {
+		if fb.book[i].snapshot().isCompileRequired {
+			return true
+		}
+	}
+	return false
+}
+
+// Book returns pointer to the reference book associated with lang.
+// If lang not found, returns the book of its base language ("pt" for
+// "pt-BR"), of the first found language of the fallback chain, see
+// WithLangFallback, or the book associated with default language.
+//
+// The book is read only: Set, Delete and SetActive do nothing, loading
+// methods return ErrReadOnly, change items through FlexBook. The
+// book keeps items as of the call, changes made later to concurrent book
+// or by Replace and loading methods are seen by the book returned by the
+// next call.
+func (b *FlexBook) Book(lang string) *Book {
+	fb := b.compiled()
+	return fb.book[fb.languageIndex(lang, &b.langs)]
+}
+
+// languageIndex returns index of the book of lang, see langIndex. Lang
+// is not interned, it can come from request.
+func (fb *flexBooks) languageIndex(lang string, l *fallbacks) int {
+	return fb.langIndex(LookupLangCode(lang), l)
+}
+
+// langIndex returns index of the book of lc, of its base language or of
+// the first found language of the fallback chain. Returns 0 if not found.
+func (fb *flexBooks) langIndex(lc LangCode, l *fallbacks) int {
 	if lc == 0 {
 		return 0
 	}
-	for i := range b.bi {
-		if b.bi[i] == lc {
+	if i := fb.bookIndex(lc); i != -1 {
+		return i
+	}
+	if i := fb.bookIndex(lc.Base()); i != -1 {
+		return i
+	}
+	for _, fl := range l.chain(lc) {
+		if i := fb.bookIndex(fl); i != -1 {
 			return i
 		}
 	}
 	return 0
 }
 
-// Name return name by id.
+// Name return name by id. If lang not found or has no item with id,
+// its base language and then languages of the fallback chain are tried.
 func (b *FlexBook) Name(lc LangCode, id int) string {
-	if len(b.book) == 1 {
-		res, ok := b.book[0].name(id)
+	fb := b.books.Load()
+	if len(fb.book) == 1 {
+		res, ok := fb.book[0].name(id)
 		if !ok {
 			return NotFoundName
 		}
 		return res
 	}
 
-	for i := range b.bi {
-		if b.bi[i] != lc {
-			continue
-		}
-
-		res, ok := b.book[i].name(id)
-		if !ok && lc != 0 {
-			res, ok = b.book[0].name(id)
+	if res, ok := fb.name(lc, id); ok {
+		return res
+	}
+	if base := lc.Base(); base != lc {
+		if res, ok := fb.name(base, id); ok {
+			return res
 		}
-		if ok {
+	}
+	for _, fl := range b.langs.chain(lc) {
+		if res, ok := fb.name(fl, id); ok {
 			return res
 		}
-		break
 	}
 	return NotFoundName
 }
 
+func (fb *flexBooks) name(lc LangCode, id int) (string, bool) {
+	if i := fb.bookIndex(lc); i != -1 {
+		return fb.book[i].name(id)
+	}
+	return "", false
+}
+
+// IDByName returns id of the item by its name in language lc. Names
+// missing in lc and taken by fallback are not matched. Returns
+// ErrNameNotFound or ErrAmbiguousName, see Book.IDByName.
+func (b *FlexBook) IDByName(lc LangCode, name string, m NameMatch) (int, error) {
+	fb := b.books.Load()
+	return fb.book[fb.langIndex(lc, &b.langs)].IDByName(lc, name, m)
+}
+
+// IDsByName returns ids of all items having the name in language lc.
+func (b *FlexBook) IDsByName(lc LangCode, name string, m NameMatch) []int {
+	fb := b.books.Load()
+	return fb.book[fb.langIndex(lc, &b.langs)].IDsByName(lc, name, m)
+}
+
 // IsExist returns true.
 func (b *FlexBook) IsExist(id int) bool {
-	if b.isConcurrent {
-		b.mux.RLock()
-	}
-	ok := b.book[0].IsExist(id)
-	if b.isConcurrent {
-		b.mux.RUnlock()
-	}
-	return ok
+	return b.books.Load().book[0].IsExist(id)
 }
 
 // Len returns reference book length.
 func (b *FlexBook) Len() int {
-	if b.isConcurrent {
-		b.mux.RLock()
-	}
-	res := b.book[0].Len()
-	if b.isConcurrent {
-		b.mux.RUnlock()
-	}
-	return res
+	return b.books.Load().book[0].Len()
 }
 
 func (b *FlexBook) BookAsJSON(lang string, dst *[]byte) {
-	if b.isConcurrent {
-		b.mux.RLock()
-	}
-	idx := b.languageIndex(lang)
-	*dst = append(*dst, b.book[idx].jsonCompiled...)
-	if b.isConcurrent {
-		b.mux.RUnlock()
-	}
+	fb := b.compiled()
+	*dst = append(*dst, fb.book[fb.languageIndex(lang, &b.langs)].JSON()...)
+}
+
+// Items returns copy of items in language lang in order given by option
+// WithOrder.
+func (b *FlexBook) Items(lang string) []Item {
+	fb := b.books.Load()
+	return fb.book[fb.languageIndex(lang, &b.langs)].Items()
 }
 
 func (b *FlexBook) Hash(lang string) uint64 {
-	if b.isConcurrent {
-		b.mux.RLock()
+	fb := b.compiled()
+	return fb.book[fb.languageIndex(lang, &b.langs)].Hash()
+}
+
+// HashAll returns hash taken out of books in all languages.
+// Returns 0 if any of the books is not optimized.
+func (b *FlexBook) HashAll() uint64 {
+	fb := b.compiled()
+
+	hs := make(map[LangCode]uint64, len(fb.bi))
+	for i := range fb.bi {
+		h := fb.book[i].Hash()
+		if h == 0 {
+			return 0
+		}
+		hs[fb.bi[i]] = h
 	}
-	idx := b.languageIndex(lang)
-	res := b.book[idx].Hash()
-	if b.isConcurrent {
-		b.mux.RUnlock()
+
+	// hash of the map does not depend on the order of languages.
+	h, err := hashstructure.Hash(hs, &hashstructure.HashOptions{})
+	if err != nil {
+		return 0
 	}
-	return res
+	return h
 }
 
 // AddItems adds items to the book.
 func (b *FlexBook) AddItems(items []Item) {
-	if b.isConcurrent {
-		b.mux.RLock()
-	}
-	if len(b.book) > 1 {
-		if b.isConcurrent {
-			b.mux.RUnlock()
-		}
+	if len(b.books.Load().book) > 1 {
 		panic("AddRows called in multilang env")
 	}
 
-	db := b.book[0]
-	if b.isConcurrent {
-		b.mux.RUnlock()
-	}
-
-	for i := range items {
-		db.Set(items[i].ID, items[i].Name)
-	}
+	_ = b.mutate(func(fb *flexBooks) error {
+		fb.addItems(items, &b.events)
+		return nil
+	})
 }
 
 // AddItem adds item to the book.
 func (b *FlexBook) AddItem(item Item) {
-	if b.isConcurrent {
-		b.mux.RLock()
-	}
-	if len(b.book) > 1 {
-		if b.isConcurrent {
-			b.mux.RUnlock()
-		}
+	b.AddItems([]Item{item})
+}
+
+// addItems adds items to the single language book, events are pushed to d.
+func (fb *flexBooks) addItems(items []Item, d *dispatcher[Event]) {
+	if len(fb.book) > 1 {
 		panic("AddRows called in multilang env")
 	}
-	db := b.book[0]
-	if b.isConcurrent {
-		b.mux.RUnlock()
+
+	s := fb.book[0].snapshot()
+	for i := range items {
+		if e, ok := fb.book[0].set(s, items[i], false); ok {
+			e.Lang = fb.bi[0]
+			d.push(e)
+		}
 	}
-	db.Set(item.ID, item.Name)
 }
 
-func (b *FlexBook) bookIndex(lc LangCode) int {
-	for i, c := range b.bi {
+func (fb *flexBooks) bookIndex(lc LangCode) int {
+	for i, c := range fb.bi {
 		if lc == c {
 			return i
 		}
@@ -239,13 +503,22 @@ func (b *FlexBook) bookIndex(lc LangCode) int {
 
 // AddMultiLangItems adds multiple items to the book.
 func (b *FlexBook) AddMultiLangItems(items []MultiLangItem) {
-	for i := range items {
-		b.AddMultiLangItem(items[i])
-	}
+	_ = b.mutate(func(fb *flexBooks) error {
+		for i := range items {
+			fb.addMultiLangItem(items[i], &b.langs, &b.events)
+		}
+		return nil
+	})
 }
 
 // AddMultiLangItem adds item to the book.
 func (b *FlexBook) AddMultiLangItem(item MultiLangItem) {
+	b.AddMultiLangItems([]MultiLangItem{item})
+}
+
+// addMultiLangItem sets names of the item in all languages, missing names
+// are taken by fallback chains. Events are pushed to d.
+func (fb *flexBooks) addMultiLangItem(item MultiLangItem, l *fallbacks, d *dispatcher[Event]) {
 
 	// make list of language codes.
 	lcs := make([]LangCode, 0, len(item.Name))
@@ -260,35 +533,158 @@ func (b *FlexBook) AddMultiLangItem(item MultiLangItem) {
 		// ignore invalid languages (lc == 0)
 	}
 
-	if b.isConcurrent {
-		b.mux.Lock()
-	}
 	// build books in missing languages.
 	for _, lc := range lcs {
-		if b.bookIndex(lc) == -1 {
-			b.bi = append(b.bi, lc)
-			b.book = append(b.book, NewBook())
+		if fb.bookIndex(lc) == -1 {
+			b := fb.newBook(lc)
+			fb.fill(b, lc, l, d)
+			fb.bi = append(fb.bi, lc)
+			fb.book = append(fb.book, b)
 		}
 	}
 
-	for _, lc := range b.bi {
-		idx := b.bookIndex(lc)
+	for idx := range fb.bi {
+		// aliases are taken in the language of the name.
+		lc := fb.bi[idx]
 		name, ok := names[lc]
+		fallback := !ok
 		if !ok {
-			name = names[b.defaultLangCode]
+			for _, fl := range l.chain(fb.bi[idx]) {
+				if name = names[fl]; name != "" {
+					lc = fl
+					break
+				}
+			}
 			if name == "" {
 				name = NotFoundName
 			}
 		}
-		b.book[idx].Set(item.ID, name)
+		if e, ok := fb.book[idx].set(fb.book[idx].snapshot(), item.item(name, item.aliases(lc)), fallback); ok {
+			e.Lang = fb.bi[idx]
+			d.push(e)
+		}
 	}
+}
+
+// fill sets items added before the new book b in language lc. Names and
+// aliases are taken by fallback chain. Events are pushed to d.
+func (fb *flexBooks) fill(b *Book, lc LangCode, l *fallbacks, d *dispatcher[Event]) {
+	s := b.snapshot()
+	for _, ni := range fb.book[0].snapshot().uItems {
+		src, found := fb.book[0].snapshot(), false
+		for _, fl := range l.chain(lc) {
+			if i := fb.bookIndex(fl); i != -1 {
+				fs := fb.book[i].snapshot()
+				if j := fs.position(ni.ID); j != -1 && !fs.uItems[j].fallback {
+					src, found = fs, true
+					break
+				}
+			}
+		}
+
+		for _, item := range src.versionsOf(ni.ID) {
+			if !found {
+				item.Name, item.Aliases = NotFoundName, nil
+			}
+			if e, ok := b.set(s, item, true); ok {
+				e.Lang = lc
+				d.push(e)
+			}
+		}
+	}
+}
+
+// Delete removes item from books in all languages.
+// Does nothing if an item does not exist.
+func (b *FlexBook) Delete(id int) {
+	if !b.IsExist(id) {
+		return
+	}
+
+	_ = b.mutate(func(fb *flexBooks) error {
+		for i := range fb.book {
+			if e, ok := fb.book[i].delete(fb.book[i].snapshot(), id); ok {
+				e.Lang = fb.bi[i]
+				b.events.push(e)
+			}
+		}
+		return nil
+	})
+}
 
+// Replace replaces all items of the book by multi language items at once.
+// Readers of concurrent book see either old or new items.
+func (b *FlexBook) Replace(items []MultiLangItem) error {
+	fb := newFlexBooks(b.defaultLangCode, b.lo)
+	if err := fb.checkPeriods(nil, items); err != nil {
+		return err
+	}
+	for i := range items {
+		fb.addMultiLangItem(items[i], &b.langs, nil)
+	}
+	return b.replace(fb)
+}
+
+// ReplaceItems replaces all items of the book by single language items at once.
+// Readers of concurrent book see either old or new items.
+func (b *FlexBook) ReplaceItems(items []Item) error {
+	fb := newFlexBooks(b.defaultLangCode, b.lo)
+	if err := fb.checkPeriods(items, nil); err != nil {
+		return err
+	}
+	fb.addItems(items, nil)
+	return b.replace(fb)
+}
+
+// replace optimizes books and swaps them in. Subscribers get events of
+// the difference between old and new books.
+func (b *FlexBook) replace(fb *flexBooks) error {
+	for i := range fb.book {
+		if err := fb.book[i].Optimize(); err != nil {
+			return err
+		}
+	}
+
+	defer b.events.flush()
 	if b.isConcurrent {
-		b.mux.Unlock()
+		b.mux.Lock()
+		defer b.mux.Unlock()
+	}
+
+	if b.events.active.Load() {
+		b.books.Load().diff(fb, &b.events)
+	}
+	b.books.Store(fb)
+	return nil
+}
+
+// diff pushes to d events turning language books fb into nfb.
+func (fb *flexBooks) diff(nfb *flexBooks, d *dispatcher[Event]) {
+	empty := newBookSnapshot[int, string](0)
+
+	for i, lc := range nfb.bi {
+		old := empty
+		if j := fb.bookIndex(lc); j != -1 {
+			old = fb.book[j].snapshot()
+		}
+		for _, e := range nfb.book[i].diff(old, nfb.book[i].snapshot()) {
+			e.Lang = lc
+			d.push(e)
+		}
+	}
+
+	for i, lc := range fb.bi {
+		if nfb.bookIndex(lc) != -1 {
+			continue
+		}
+		for _, e := range fb.book[i].diff(fb.book[i].snapshot(), empty) {
+			e.Lang = lc
+			d.push(e)
+		}
 	}
 }
 
-func (b *FlexBook) LoadFromSlice(src interface{}, attrid string, attrname string) error {
+func (b *FlexBook) LoadFromSlice(src interface{}, attrid string, attrname string, f ...func(*SliceOption)) error {
 
 	if src == nil {
 		return nil
@@ -316,21 +712,70 @@ func (b *FlexBook) LoadFromSlice(src interface{}, attrid string, attrname string
 		return fmt.Errorf("attribute %s not found", attrname)
 	}
 
+	var (
+		items   []Item
+		mlItems []MultiLangItem
+	)
+
+	o := newSliceOption(f)
 	for i := 0; i < s.Len(); i++ {
 		item := s.Index(i)
 
+		from, to, err := o.validity(item)
+		if err != nil {
+			return err
+		}
+
 		if nf.Kind() == reflect.String {
-			b.AddItem(Item{ID: int(item.FieldByName(attrid).Int()), Name: item.FieldByName(attrname).String()})
+			items = append(items, Item{
+				ID:        int(item.FieldByName(attrid).Int()),
+				Name:      item.FieldByName(attrname).String(),
+				ValidFrom: from,
+				ValidTo:   to,
+			})
 		} else {
 			m := map[string]string{}
 			err := json.Unmarshal(item.FieldByName(attrname).Bytes(), &m)
 			if err != nil {
 				return err
 			}
-			b.AddMultiLangItem(MultiLangItem{ID: int(item.FieldByName(attrid).Int()), Name: m})
+			mlItems = append(mlItems, MultiLangItem{
+				ID:        int(item.FieldByName(attrid).Int()),
+				Name:      m,
+				ValidFrom: from,
+				ValidTo:   to,
+			})
 		}
 	}
-	return nil
+
+	return b.add(items, mlItems)
+}
+
+// add adds items to the book. Returns error and adds nothing if items
+// don't pass check.
+func (b *FlexBook) add(items []Item, mlItems []MultiLangItem) error {
+	return b.mutate(func(fb *flexBooks) error {
+		if err := fb.checkPeriods(items, mlItems); err != nil {
+			return err
+		}
+		if len(items) > 0 {
+			fb.addItems(items, &b.events)
+		}
+		for i := range mlItems {
+			fb.addMultiLangItem(mlItems[i], &b.langs, &b.events)
+		}
+		return nil
+	})
+}
+
+// checkPeriods returns error if items added to the books have invalid
+// validity periods, see checkPeriods.
+func (fb *flexBooks) checkPeriods(items []Item, mlItems []MultiLangItem) error {
+	all := append(make([]Item, 0, len(items)+len(mlItems)), items...)
+	for i := range mlItems {
+		all = append(all, mlItems[i].item("", nil))
+	}
+	return checkPeriods(fb.book[0].snapshot(), all)
 }
 
 // Parse recognizes input JSON presented as [{"id": 1, "name" : "Hello"},..] or
@@ -374,8 +819,7 @@ func (b *FlexBook) Parse(src []byte) error {
 			return err
 		}
 
-		b.AddMultiLangItems(items)
-		return nil
+		return b.add(nil, items)
 	}
 
 	if sl > 0 {
@@ -384,18 +828,55 @@ func (b *FlexBook) Parse(src []byte) error {
 			return err
 		}
 
-		b.AddItems(items)
+		return b.add(items, nil)
 	}
 	return nil
 }
 
+// Optimize calculates hash and pre-generates JSON of books in all languages.
 func (b *FlexBook) Optimize() error {
-	b.mux.RLock()
-	defer b.mux.RUnlock()
-	for i := range b.book {
-		if err := b.book[i].optimize(); err != nil {
-			return err
+	return b.optimizeIfRequired(true)
+}
+
+// optimizeIfRequired optimizes books if they have been changed since
+// the last optimization or force is true. Optimize of not concurrent book
+// modifies books in place, lazy optimization on read builds new ones
+// because readers of any book can run concurrently.
+func (b *FlexBook) optimizeIfRequired(force bool) error {
+	if !b.isConcurrent && force {
+		fb := b.books.Load()
+		for i := range fb.book {
+			if err := fb.book[i].Optimize(); err != nil {
+				return err
+			}
 		}
+		return nil
 	}
-	return nil
+	_, err := b.recompile(force)
+	return err
+}
+
+// recompile publishes optimized copies of the current books if they have
+// been changed or force is true. Returns the published books.
+func (b *FlexBook) recompile(force bool) (*flexBooks, error) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	fb := b.books.Load()
+	if !force && !fb.isCompileRequired() {
+		return fb, nil
+	}
+
+	// items are not changed, shallow copies of snapshots are enough.
+	nfb := flexBooks{bi: fb.bi, book: make([]*Book, len(fb.book)), lo: fb.lo}
+	for i := range fb.book {
+		s := *fb.book[i].snapshot()
+		if err := fb.book[i].optimize(&s); err != nil {
+			return fb, err
+		}
+		nfb.book[i] = fb.book[i].emptyCopy()
+		nfb.book[i].snap.Store(&s)
+	}
+	b.books.Store(&nfb)
+	return &nfb, nil
 }
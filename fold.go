@@ -0,0 +1,117 @@
+package refbook
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// foldPool keeps transformers used by fold, they are not safe for
+// concurrent use.
+var foldPool = sync.Pool{
+	New: func() interface{} {
+		return transform.Chain(
+			norm.NFD,
+			runes.Remove(runes.In(unicode.Mn)),
+			cases.Fold(),
+			runes.Map(foldRune),
+		)
+	},
+}
+
+// foldRune maps letters having no decomposition to their base letters.
+func foldRune(r rune) rune {
+	switch r {
+	case 'ı':
+		return 'i'
+	case 'ł':
+		return 'l'
+	case 'đ':
+		return 'd'
+	case 'ø':
+		return 'o'
+	case 'ħ':
+		return 'h'
+	}
+	return r
+}
+
+// fold returns s in the form insensitive to case and diacritics:
+// "Société" -> "societe", "Straße" -> "strasse", "İstanbul" -> "istanbul".
+func fold(s string) string {
+	if isASCII(s) {
+		return strings.ToLower(s)
+	}
+
+	t := foldPool.Get().(transform.Transformer)
+	defer foldPool.Put(t)
+
+	res, _, err := transform.String(t, s)
+	if err != nil {
+		return strings.ToLower(s)
+	}
+	return res
+}
+
+// foldRunes caches folded forms of non-ASCII runes.
+var foldRunes sync.Map
+
+// foldOffsets returns fold(s) and offsets in s of all bytes of the result,
+// plus len(s) at the end. Offsets are nil if rune of the result at byte i
+// comes from rune of s at byte i.
+func foldOffsets(s string) (string, []int32) {
+	if isASCII(s) {
+		return strings.ToLower(s), nil
+	}
+
+	var (
+		sb   strings.Builder
+		offs []int32
+	)
+	sb.Grow(len(s))
+
+	for i, r := range s {
+		var f string
+		if r < utf8.RuneSelf {
+			f = string(unicode.ToLower(r))
+		} else if v, ok := foldRunes.Load(r); ok {
+			f = v.(string)
+		} else {
+			f = fold(string(r))
+			foldRunes.Store(r, f)
+		}
+
+		if offs == nil && (sb.Len() != i || len(f) != utf8.RuneLen(r) || utf8.RuneCountInString(f) != 1) {
+			offs = make([]int32, sb.Len(), len(s)+1)
+			for j := range offs {
+				offs[j] = int32(j)
+			}
+		}
+		if offs != nil {
+			for j := 0; j < len(f); j++ {
+				offs = append(offs, int32(i))
+			}
+		}
+		sb.WriteString(f)
+	}
+
+	if offs != nil {
+		offs = append(offs, int32(len(s)))
+	}
+	return sb.String(), offs
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
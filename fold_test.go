@@ -0,0 +1,96 @@
+package refbook
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFold(t *testing.T) {
+
+	tc := []struct {
+		src      string
+		expected string
+	}{
+		{"Hello", "hello"},
+		{"Société", "societe"},
+		{"Straße", "strasse"},
+		{"İstanbul", "istanbul"},
+		{"ISTANBUL", "istanbul"},
+		{"ılık", "ilik"},
+		{"Łódź", "lodz"},
+		{"Ёжик", "ежик"},
+		{"ΣΊΣΥΦΟΣ", "σισυφοσ"},
+	}
+
+	for i := range tc {
+		t.Run(tc[i].src, func(t *testing.T) {
+			if res := fold(tc[i].src); res != tc[i].expected {
+				t.Errorf("expected %s, got %s", tc[i].expected, res)
+			}
+		})
+	}
+}
+
+func TestFoldOffsets(t *testing.T) {
+
+	for _, src := range []string{"Hello", "Société", "Straße", "İstanbul", "Ёжик", "e\u0301te"} {
+		res, offs := foldOffsets(src)
+		if res != fold(src) {
+			t.Errorf("expected %s, got %s", fold(src), res)
+		}
+		if offs == nil {
+			continue
+		}
+		if len(offs) != len(res)+1 || offs[len(res)] != int32(len(src)) {
+			t.Errorf("%s: unexpected offsets %v", src, offs)
+		}
+		for i := 1; i < len(offs); i++ {
+			if offs[i] < offs[i-1] {
+				t.Errorf("%s: offsets must not decrease %v", src, offs)
+			}
+		}
+	}
+}
+
+func TestBook_ContainsMatch(t *testing.T) {
+
+	b := NewBook()
+	if err := b.Parse([]byte(`[{"id":1,"name":"Société Générale"},{"id":2,"name":"Straße"},{"id":3,"name":"İstanbul Bank"},{"id":4,"name":"societe"}]`)); err != nil {
+		t.Fatal(err)
+	}
+
+	tc := []struct {
+		s        string
+		m        NameMatch
+		expected []int
+	}{
+		{"Societe", MatchFold, []int{1, 4}},
+		{"GENERALE", MatchFold, []int{1}},
+		{"STRASSE", MatchFold, []int{2}},
+		{"istanbul", MatchFold, []int{3}},
+		{"Societe", MatchCaseInsensitive, []int{4}},
+		{"Soci", MatchExact, []int{1}},
+	}
+
+	var dst []int
+	for i := range tc {
+		t.Run(tc[i].s, func(t *testing.T) {
+			b.ContainsMatch(tc[i].s, tc[i].m, &dst)
+			if !reflect.DeepEqual(dst, tc[i].expected) {
+				t.Errorf("expected %v, got %v", tc[i].expected, dst)
+			}
+		})
+	}
+
+	if ids := b.IDsByName(0, "SOCIETE", MatchFold); !reflect.DeepEqual(ids, []int{4}) {
+		t.Errorf("expected [4], got %v", ids)
+	}
+
+	dst = make([]int, 0, 4)
+	n := testing.AllocsPerRun(100, func() {
+		b.ContainsMatch("societe", MatchFold, &dst)
+	})
+	if n != 0 {
+		t.Errorf("expected no allocations, got %v", n)
+	}
+}
@@ -1,9 +1,15 @@
 module github.com/axkit/refbook
 
-go 1.13
+go 1.21
 
 require (
 	github.com/lib/pq v1.8.0
 	github.com/mitchellh/hashstructure v1.1.0
 	github.com/tidwall/gjson v1.14.0
+	golang.org/x/text v0.14.0
+)
+
+require (
+	github.com/tidwall/match v1.1.1 // indirect
+	github.com/tidwall/pretty v1.2.0 // indirect
 )
@@ -0,0 +1,199 @@
+package refbook
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultCacheControl is the value of Cache-Control header used by Handler
+// if option WithCacheControl is not given. Clients keep the response but
+// revalidate it by ETag on each request.
+var DefaultCacheControl = "no-cache"
+
+// Handler implements http.Handler. It serves reference book as
+// precompiled JSON. Hash of the book is used as a strong ETag.
+// JSON precompressed by options WithGzip and WithEncoder is served
+// according to header Accept-Encoding.
+type Handler struct {
+	book         func(r *http.Request) *Book
+	cacheControl string
+	vary         string
+}
+
+// HandlerOption holds Handler configuration.
+type HandlerOption struct {
+	cacheControl string
+}
+
+// WithCacheControl replaces DefaultCacheControl.
+func WithCacheControl(cacheControl string) func(o *HandlerOption) {
+	return func(o *HandlerOption) {
+		o.cacheControl = cacheControl
+	}
+}
+
+func newHandlerOption(f []func(*HandlerOption)) HandlerOption {
+	o := HandlerOption{cacheControl: DefaultCacheControl}
+	for i := range f {
+		f[i](&o)
+	}
+	return o
+}
+
+// NewHandler returns handler serving the book.
+func NewHandler(b *Book, f ...func(*HandlerOption)) *Handler {
+	o := newHandlerOption(f)
+	return &Handler{
+		book:         func(*http.Request) *Book { return b },
+		cacheControl: o.cacheControl,
+	}
+}
+
+// NewFlexHandler returns handler serving the book in the language requested
+// by query parameter "lang" or by header Accept-Language. The book in
+// default language is served if requested language is not found.
+func NewFlexHandler(b *FlexBook, f ...func(*HandlerOption)) *Handler {
+	o := newHandlerOption(f)
+	return &Handler{
+		book:         func(r *http.Request) *Book { return b.Book(requestLang(b, r)) },
+		cacheControl: o.cacheControl,
+		vary:         "Accept-Language",
+	}
+}
+
+// requestLang returns the language of request supported by the book.
+// Returns empty string if no one is supported.
+func requestLang(b *FlexBook, r *http.Request) string {
+	if lang := r.URL.Query().Get("lang"); lang != "" {
+		return lang
+	}
+
+	fb := b.books.Load()
+	for _, lang := range accepted(r.Header.Get("Accept-Language")) {
+		lc := LookupLangCode(lang)
+		if fb.bookIndex(lc) != -1 || b.langs.chains[lc] != nil {
+			return lang
+		}
+		// en-US is served by en.
+		if base := lc.Base(); fb.bookIndex(base) != -1 || b.langs.chains[base] != nil {
+			return base.String()
+		}
+	}
+	return ""
+}
+
+// accepted parses header Accept-Language or Accept-Encoding and returns
+// values in order of preference.
+func accepted(header string) []string {
+	type lq struct {
+		lang string
+		q    float64
+	}
+
+	var res []lq
+	for _, part := range strings.Split(header, ",") {
+		f := strings.Split(part, ";")
+		lang := strings.TrimSpace(f[0])
+		if lang == "" || lang == "*" {
+			continue
+		}
+
+		q := 1.0
+		for _, p := range f[1:] {
+			p = strings.TrimSpace(p)
+			if strings.HasPrefix(p, "q=") {
+				if v, err := strconv.ParseFloat(p[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			res = append(res, lq{lang, q})
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool { return res[i].q > res[j].q })
+
+	langs := make([]string, len(res))
+	for i := range res {
+		langs[i] = res[i].lang
+	}
+	return langs
+}
+
+// etagMatch returns true if header If-None-Match contains etag.
+func etagMatch(header, etag string) bool {
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// ServeHTTP implements interface http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	// JSON and hash are taken from the same snapshot. The book not
+	// optimized yet is compiled on demand.
+	b := h.book(r)
+	s := b.compiled()
+	if s.isCompileRequired {
+		var err error
+		if s, err = b.recompile(false); err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	hdr := w.Header()
+	hdr.Set("Cache-Control", h.cacheControl)
+	if h.vary != "" {
+		hdr.Add("Vary", h.vary)
+	}
+
+	body, coding := s.jsonCompiled, ""
+	if len(s.jsonEncoded) > 0 {
+		hdr.Add("Vary", "Accept-Encoding")
+		for _, c := range accepted(r.Header.Get("Accept-Encoding")) {
+			if buf := s.encoded(strings.ToLower(c)); buf != nil {
+				body, coding = buf, strings.ToLower(c)
+				break
+			}
+		}
+	}
+
+	if s.jsonInput.Hash != 0 {
+		// decimal like hash in JSON and manifest. Representations in
+		// different encodings have different tags.
+		etag := strconv.FormatUint(s.jsonInput.Hash, 10)
+		if coding != "" {
+			etag += "-" + coding
+		}
+		etag = `"` + etag + `"`
+		hdr.Set("ETag", etag)
+		if inm := r.Header.Get("If-None-Match"); inm != "" && etagMatch(inm, etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	hdr.Set("Content-Type", "application/json; charset=utf-8")
+	if coding != "" {
+		hdr.Set("Content-Encoding", coding)
+	}
+	hdr.Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodGet {
+		_, _ = w.Write(body)
+	}
+}
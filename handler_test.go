@@ -0,0 +1,266 @@
+package refbook
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+
+	b := NewConcurrentBook()
+	if err := b.Parse([]byte(`[{"id":1,"name":"A"},{"id":2,"name":"B"}]`)); err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewHandler(b, WithCacheControl("public, max-age=60"))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/party_types", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if w.Body.String() != string(b.JSON()) {
+		t.Errorf("unexpected body %s", w.Body.String())
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != "public, max-age=60" {
+		t.Errorf("unexpected Cache-Control %s", cc)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json; charset=utf-8" {
+		t.Errorf("unexpected Content-Type %s", ct)
+	}
+
+	etag := w.Header().Get("ETag")
+	if etag != `"`+strconv.FormatUint(b.Hash(), 10)+`"` {
+		t.Fatalf("expected ETag equal to hash, got %s", etag)
+	}
+
+	tc := []struct {
+		name     string
+		inm      string
+		expected int
+	}{
+		{"match", etag, http.StatusNotModified},
+		{"weak", "W/" + etag, http.StatusNotModified},
+		{"list", `"abc", ` + etag, http.StatusNotModified},
+		{"any", "*", http.StatusNotModified},
+		{"mismatch", `"abc"`, http.StatusOK},
+	}
+
+	for i := range tc {
+		t.Run(tc[i].name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/party_types", nil)
+			r.Header.Set("If-None-Match", tc[i].inm)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tc[i].expected {
+				t.Errorf("expected %d, got %d", tc[i].expected, w.Code)
+			}
+			if w.Code == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Error("expected empty body")
+			}
+		})
+	}
+
+	// changed book gets new ETag.
+	b.Set(3, "C")
+	if err := b.Optimize(); err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/party_types", nil)
+	r.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Errorf("expected 200 with new ETag, got %d %s", w.Code, w.Header().Get("ETag"))
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/party_types", nil))
+	if w.Code != http.StatusOK || w.Body.Len() != 0 || w.Header().Get("Content-Length") == "" {
+		t.Errorf("unexpected HEAD response %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/party_types", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", w.Code)
+	}
+}
+
+func TestHandler_NotOptimized(t *testing.T) {
+
+	for _, b := range []*Book{NewBook(), NewConcurrentBook()} {
+		b.Set(1, "A")
+
+		w := httptest.NewRecorder()
+		NewHandler(b).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if w.Code != http.StatusOK || w.Body.String() != string(b.JSON()) || w.Body.Len() == 0 {
+			t.Errorf("unexpected response %d %s", w.Code, w.Body.String())
+		}
+		if etag := w.Header().Get("ETag"); etag != `"`+strconv.FormatUint(b.Hash(), 10)+`"` || b.Hash() == 0 {
+			t.Errorf("unexpected ETag %s", etag)
+		}
+	}
+
+	fb := NewFlexBook()
+	fb.AddMultiLangItem(MultiLangItem{ID: 1, Name: map[string]string{"en": "A", "de": "B"}})
+	w := httptest.NewRecorder()
+	NewFlexHandler(fb).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?lang=de", nil))
+	if w.Code != http.StatusOK || !bytes.Contains(w.Body.Bytes(), []byte(`"B"`)) || w.Header().Get("ETag") == "" {
+		t.Errorf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestFlexHandler(t *testing.T) {
+
+	b := NewFlexBook(WithThreadSafe(), WithDefaultLang("en"), WithAutoOptimize(0))
+	src := []byte(`[{"id":1,"name":{"en":"A","ru":"AA","de":"AAA"}}]`)
+	if err := b.Parse(src); err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewFlexHandler(b)
+
+	tc := []struct {
+		name     string
+		url      string
+		accept   string
+		expected string
+	}{
+		{"default", "/", "", "en"},
+		{"query", "/?lang=ru", "de", "ru"},
+		{"header", "/", "de", "de"},
+		{"quality", "/", "fr;q=1, ru;q=0.5, de;q=0.7", "de"},
+		{"region", "/", "ru-RU,en;q=0.8", "ru"},
+		{"unknown", "/", "fr", "en"},
+		{"unknown query", "/?lang=fr", "", "en"},
+	}
+
+	for i := range tc {
+		t.Run(tc[i].name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tc[i].url, nil)
+			if tc[i].accept != "" {
+				r.Header.Set("Accept-Language", tc[i].accept)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			var expected []byte
+			b.BookAsJSON(tc[i].expected, &expected)
+			if w.Body.String() != string(expected) {
+				t.Errorf("expected %s, got %s", expected, w.Body.String())
+			}
+			if w.Header().Get("Vary") != "Accept-Language" {
+				t.Error("expected Vary header")
+			}
+			if w.Header().Get("Cache-Control") != DefaultCacheControl {
+				t.Error("expected default Cache-Control")
+			}
+		})
+	}
+}
+
+func TestHandler_Gzip(t *testing.T) {
+
+	b := NewBook(WithGzip())
+	if err := b.Parse([]byte(`[{"id":1,"name":"A"},{"id":2,"name":"B"}]`)); err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewHandler(b)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Encoding", "br;q=1, gzip;q=0.8")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if ce := w.Header().Get("Content-Encoding"); ce != "gzip" {
+		t.Fatalf("expected Content-Encoding gzip, got %q", ce)
+	}
+	if res := gunzip(t, w.Body.Bytes()); string(res) != string(b.JSON()) {
+		t.Errorf("unexpected body %s", res)
+	}
+	if w.Header().Get("Vary") != "Accept-Encoding" {
+		t.Error("expected Vary header")
+	}
+	if w.Header().Get("Content-Length") != strconv.Itoa(w.Body.Len()) {
+		t.Errorf("unexpected Content-Length %s", w.Header().Get("Content-Length"))
+	}
+	etag := w.Header().Get("ETag")
+
+	// identity representation has another tag.
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Header().Get("Content-Encoding") != "" || w.Body.String() != string(b.JSON()) {
+		t.Errorf("expected uncompressed body, got %q", w.Body.String())
+	}
+	if w.Header().Get("ETag") == etag {
+		t.Error("expected different ETag")
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	r.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("expected 304, got %d", w.Code)
+	}
+}
+
+func TestAcceptedLangs(t *testing.T) {
+
+	tc := []struct {
+		header   string
+		expected []string
+	}{
+		{"", []string{}},
+		{"ru", []string{"ru"}},
+		{"en;q=0.5, ru, *;q=0.1, de;q=0", []string{"ru", "en"}},
+		{"fr-CH, fr;q=0.9, en;q=0.8, de;q=0.7", []string{"fr-CH", "fr", "en", "de"}},
+	}
+
+	for i := range tc {
+		t.Run(tc[i].header, func(t *testing.T) {
+			if res := accepted(tc[i].header); !reflect.DeepEqual(res, tc[i].expected) {
+				t.Errorf("expected %v, got %v", tc[i].expected, res)
+			}
+		})
+	}
+}
+
+func TestFlexHandler_LangNotInterned(t *testing.T) {
+
+	b := NewFlexBook(WithDefaultLang("en"))
+	if err := b.Parse([]byte(`[{"id":1,"name":{"en":"A","ru":"AA"}}]`)); err != nil {
+		t.Fatal(err)
+	}
+	b.Optimize()
+
+	h := NewFlexHandler(b)
+	n := len(langTags.Load().tags)
+	for i := 0; i < 100; i++ {
+		r := httptest.NewRequest(http.MethodGet, "/?lang=qq-Junk"+strconv.Itoa(i), nil)
+		r.Header.Set("Accept-Language", "zz-X"+strconv.Itoa(i)+", ru-Junk"+strconv.Itoa(i))
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	if m := len(langTags.Load().tags); m != n {
+		t.Errorf("expected %d interned tags, got %d", n, m)
+	}
+
+	var expected []byte
+	b.BookAsJSON("ru-Junk1", &expected)
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Language", "ru-Junk1")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Body.String() != string(expected) || !bytes.Contains(expected, []byte(`"AA"`)) {
+		t.Errorf("expected %s, got %s", expected, w.Body.String())
+	}
+}
@@ -0,0 +1,194 @@
+package refbook
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// LangCode is a compact code of the BCP 47 language tag. Two letter
+// language ("en") is encoded by its letters, longer tags ("pt-BR",
+// "zh-Hant", "yue") are interned on first use. Zero means default
+// language.
+type LangCode uint32
+
+const (
+	// maxLangTags limits number of interned tags, longer tags coming after
+	// the limit are reduced to their base language.
+	maxLangTags = 1024
+
+	firstInterned LangCode = 1 << 16
+)
+
+// langTable holds interned language tags. It's never modified after it
+// has been published.
+type langTable struct {
+	codes map[string]LangCode
+	tags  []langTag // indexed by code - firstInterned.
+}
+
+type langTag struct {
+	tag  string
+	base LangCode
+}
+
+var (
+	langMux  sync.Mutex // serializes interning.
+	langTags atomic.Pointer[langTable]
+)
+
+func init() {
+	langTags.Store(&langTable{codes: map[string]LangCode{}})
+}
+
+// ToLangCode returns code of the language tag. Tags are case insensitive,
+// "_" can be used instead of "-". Returns 0 if the tag is not valid.
+//
+// Unknown tags are interned into the process wide table of limited size,
+// use LookupLangCode for untrusted input like headers of requests.
+func ToLangCode(src string) LangCode {
+	if len(src) == 2 {
+		if !isAlpha(src[0]) || !isAlpha(src[1]) {
+			return 0
+		}
+		return LangCode(uint32(src[0]|0x20)<<8 | uint32(src[1]|0x20))
+	}
+
+	tag, ok := canonicalTag(src)
+	if !ok {
+		return 0
+	}
+	if len(tag) == 2 {
+		return ToLangCode(tag)
+	}
+	if lc, ok := langTags.Load().codes[tag]; ok {
+		return lc
+	}
+	return intern(tag)
+}
+
+// LookupLangCode returns code of the language tag like ToLangCode, but
+// never interns the tag. Tag not interned yet is reduced to its base
+// language. Returns 0 if neither the tag nor its base is known.
+func LookupLangCode(src string) LangCode {
+	if len(src) == 2 {
+		return ToLangCode(src)
+	}
+
+	tag, ok := canonicalTag(src)
+	if !ok {
+		return 0
+	}
+	if len(tag) == 2 {
+		return ToLangCode(tag)
+	}
+	if lc, ok := langTags.Load().codes[tag]; ok {
+		return lc
+	}
+	if i := strings.IndexByte(tag, '-'); i > 0 {
+		return LookupLangCode(tag[:i])
+	}
+	return 0
+}
+
+// intern adds the tag to the table of language tags.
+func intern(tag string) LangCode {
+
+	base := LangCode(0)
+	if i := strings.IndexByte(tag, '-'); i > 0 {
+		base = ToLangCode(tag[:i])
+	}
+
+	langMux.Lock()
+	defer langMux.Unlock()
+
+	t := langTags.Load()
+	if lc, ok := t.codes[tag]; ok {
+		return lc
+	}
+	if len(t.tags) >= maxLangTags {
+		return base
+	}
+
+	lc := firstInterned + LangCode(len(t.tags))
+	if base == 0 {
+		base = lc
+	}
+
+	nt := langTable{
+		codes: make(map[string]LangCode, len(t.codes)+1),
+		tags:  append(t.tags[:len(t.tags):len(t.tags)], langTag{tag: tag, base: base}),
+	}
+	for k, v := range t.codes {
+		nt.codes[k] = v
+	}
+	nt.codes[tag] = lc
+	langTags.Store(&nt)
+	return lc
+}
+
+// canonicalTag validates the tag and returns it in canonical case:
+// language in lower case, script in title case, region in upper case
+// ("zh-hant-tw" -> "zh-Hant-TW").
+func canonicalTag(src string) (string, bool) {
+	if len(src) == 0 || len(src) > 35 {
+		return "", false
+	}
+
+	subtags := strings.FieldsFunc(src, func(r rune) bool { return r == '-' || r == '_' })
+	if len(subtags) == 0 || strings.Count(src, "-")+strings.Count(src, "_") != len(subtags)-1 {
+		return "", false
+	}
+
+	for i, st := range subtags {
+		if len(st) > 8 || i == 0 && len(st) < 2 {
+			return "", false
+		}
+		for j := 0; j < len(st); j++ {
+			if !isAlpha(st[j]) && (i == 0 || st[j] < '0' || st[j] > '9') {
+				return "", false
+			}
+		}
+
+		st = strings.ToLower(st)
+		switch {
+		case i > 0 && len(st) == 4 && isAlpha(st[0]):
+			st = strings.ToUpper(st[:1]) + st[1:]
+		case i > 0 && len(st) == 2:
+			st = strings.ToUpper(st)
+		}
+		subtags[i] = st
+	}
+	return strings.Join(subtags, "-"), true
+}
+
+func isAlpha(c byte) bool {
+	c |= 0x20
+	return c >= 'a' && c <= 'z'
+}
+
+// Base returns code of the language without script, region and other
+// subtags ("pt" for "pt-BR").
+func (lc LangCode) Base() LangCode {
+	if lc < firstInterned {
+		return lc
+	}
+	if t := langTags.Load().tags; int(lc-firstInterned) < len(t) {
+		return t[lc-firstInterned].base
+	}
+	return 0
+}
+
+// String returns the language tag.
+func (lc LangCode) String() string {
+	switch {
+	case lc == 0:
+		return ""
+	case lc < firstInterned:
+		return string([]byte{byte(lc >> 8), byte(lc)})
+	}
+	if t := langTags.Load().tags; int(lc-firstInterned) < len(t) {
+		return t[lc-firstInterned].tag
+	}
+	return ""
+}
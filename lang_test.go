@@ -0,0 +1,135 @@
+package refbook
+
+import (
+	"testing"
+)
+
+func TestToLangCode(t *testing.T) {
+
+	tc := []struct {
+		src      string
+		expected string
+	}{
+		{"en", "en"},
+		{"EN", "en"},
+		{"pt-BR", "pt-BR"},
+		{"pt_br", "pt-BR"},
+		{"zh-hant-tw", "zh-Hant-TW"},
+		{"sr-Latn", "sr-Latn"},
+		{"yue", "yue"},
+		{"es-419", "es-419"},
+		{"", ""},
+		{"e", ""},
+		{"e1", ""},
+		{"pt--BR", ""},
+		{"pt-", ""},
+		{"p-BR", ""},
+		{"pt-BRAZILIAN", ""},
+	}
+
+	for i := range tc {
+		t.Run(tc[i].src, func(t *testing.T) {
+			if res := ToLangCode(tc[i].src).String(); res != tc[i].expected {
+				t.Errorf("expected %q, got %q", tc[i].expected, res)
+			}
+		})
+	}
+
+	if ToLangCode("pt-BR") != ToLangCode("PT-br") {
+		t.Error("tags expected to be case insensitive")
+	}
+	if ToLangCode("pt-BR").Base() != ToLangCode("pt") {
+		t.Error("expected base pt")
+	}
+	if lc := ToLangCode("yue-HK"); lc.Base() != ToLangCode("yue") {
+		t.Errorf("expected base yue, got %s", lc.Base())
+	}
+	if lc := ToLangCode("en"); lc.Base() != lc {
+		t.Error("two letter language expected to be the base")
+	}
+}
+
+func TestToLangCode_Allocs(t *testing.T) {
+
+	b := NewFlexBook(WithDefaultLang("en"))
+	b.AddMultiLangItem(MultiLangItem{ID: 1, Name: map[string]string{"en": "A", "ru": "AA"}})
+
+	n := testing.AllocsPerRun(100, func() {
+		if b.Name(ToLangCode("ru"), 1) != "AA" {
+			t.Fatal("unexpected name")
+		}
+	})
+	if n != 0 {
+		t.Errorf("expected no allocations, got %v", n)
+	}
+}
+
+func TestFlexBook_LangTags(t *testing.T) {
+
+	b := NewFlexBook(WithDefaultLang("en"), WithLangFallback("zh-Hant", "zh"))
+	b.AddMultiLangItems([]MultiLangItem{
+		{ID: 1, Name: map[string]string{"en": "Color", "pt": "Cor", "zh": "颜色", "sr-Latn": "Boja", "sr-Cyrl": "Боја"}},
+		{ID: 2, Name: map[string]string{"en": "Size", "pt": "Tamanho", "pt-BR": "Tamanho BR"}},
+	})
+	if err := b.Optimize(); err != nil {
+		t.Fatal(err)
+	}
+
+	tc := []struct {
+		lang     string
+		id       int
+		expected string
+	}{
+		{"pt-BR", 2, "Tamanho BR"},
+		{"pt-br", 2, "Tamanho BR"},
+		{"pt-PT", 2, "Tamanho"},
+		{"pt", 2, "Tamanho"},
+		{"sr-Latn", 1, "Boja"},
+		{"sr-Cyrl", 1, "Боја"},
+		{"zh-Hant", 1, "颜色"},
+		{"zh-TW", 1, "颜色"},
+		{"de-DE", 1, "Color"},
+	}
+
+	for i := range tc {
+		t.Run(tc[i].lang, func(t *testing.T) {
+			if name := b.Name(ToLangCode(tc[i].lang), tc[i].id); name != tc[i].expected {
+				t.Errorf("expected %s, got %s", tc[i].expected, name)
+			}
+		})
+	}
+
+	if b.Book("pt-PT") != b.Book("pt") {
+		t.Error("expected book pt for pt-PT")
+	}
+	if b.Book("pt-BR") == b.Book("pt") {
+		t.Error("expected own book for pt-BR")
+	}
+}
+
+func TestLookupLangCode(t *testing.T) {
+
+	n := len(langTags.Load().tags)
+	tcs := []struct {
+		src      string
+		expected LangCode
+	}{
+		{"ru", ToLangCode("ru")},
+		{"ru-Xyz1", ToLangCode("ru")},
+		{"zzz-Abcd", 0},
+		{"12", 0},
+	}
+	for _, tc := range tcs {
+		if lc := LookupLangCode(tc.src); lc != tc.expected {
+			t.Errorf("%s: expected %v, got %v", tc.src, tc.expected, lc)
+		}
+	}
+	if m := len(langTags.Load().tags); m != n {
+		t.Errorf("expected %d interned tags, got %d", n, m)
+	}
+
+	lc := ToLangCode("sr-Latn")
+	if LookupLangCode("SR_latn") != lc {
+		t.Errorf("expected interned code %v", lc)
+	}
+}
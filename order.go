@@ -0,0 +1,113 @@
+package refbook
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// Order defines order of items returned by Traverse, Items and JSON.
+type Order int
+
+const (
+	// OrderInsertion keeps items in order they have been added.
+	OrderInsertion Order = iota
+
+	// OrderSortOrder orders items by attribute sort_order, items having
+	// equal sort_order are ordered by name.
+	OrderSortOrder
+
+	// OrderName orders items by name according to collation rules of the
+	// book language, so "Ölhandel" is placed between "Obst" and "Post" in
+	// German book.
+	OrderName
+)
+
+// WithOrder sets order of items. Language of Book is given by
+// WithDefaultLang, language books of FlexBook use their own languages.
+// Hash of the book does not depend on order.
+func WithOrder(order Order) func(o *Option) {
+	return func(o *Option) {
+		o.order = order
+	}
+}
+
+// sorter keeps items of the book in order.
+type sorter struct {
+	order Order
+	mux   sync.Mutex // collator is not safe for concurrent use.
+	col   *collate.Collator
+	buf   collate.Buffer
+}
+
+// newSorter returns nil if items are kept in order of insertion.
+func newSorter(order Order, lc LangCode) *sorter {
+	if order == OrderInsertion {
+		return nil
+	}
+
+	tag, err := language.Parse(lc.String())
+	if err != nil {
+		tag = language.Und
+	}
+	return &sorter{order: order, col: collate.New(tag)}
+}
+
+// key returns collation key of name.
+func (st *sorter) key(name string) []byte {
+	k := st.col.KeyFromString(&st.buf, name)
+	res := append(make([]byte, 0, len(k)), k...)
+	st.buf.Reset()
+	return res
+}
+
+// sort orders items of the snapshot. The snapshot is modified only if
+// it's out of order or names have no collation keys, so snapshot sorted
+// before publishing is never modified.
+func (s *bookSnapshot[K, V]) sort(st *sorter) {
+	if st == nil {
+		return
+	}
+
+	st.mux.Lock()
+	defer st.mux.Unlock()
+
+	for i := range s.uItems {
+		if s.uItems[i].key == nil {
+			s.uItems[i].key = st.key(s.uItems[i].Name)
+		}
+	}
+
+	less := func(i, j int) bool {
+		if st.order == OrderSortOrder {
+			if a, b := s.jsonInput.Items[i].SortOrder, s.jsonInput.Items[j].SortOrder; a != b {
+				return a < b
+			}
+		}
+		return bytes.Compare(s.uItems[i].key, s.uItems[j].key) < 0
+	}
+
+	isSorted := true
+	for i := 1; i < len(s.uItems) && isSorted; i++ {
+		isSorted = !less(i, i-1)
+	}
+	if isSorted {
+		return
+	}
+
+	idx := make([]int, len(s.uItems))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool { return less(idx[i], idx[j]) })
+
+	items := make([]ItemOf[K, V], len(idx), cap(s.jsonInput.Items))
+	uItems := make([]nameItem[K], len(idx), cap(s.uItems))
+	for i, j := range idx {
+		items[i], uItems[i] = s.jsonInput.Items[j], s.uItems[j]
+	}
+	s.jsonInput.Items, s.uItems = items, uItems
+}
@@ -0,0 +1,160 @@
+package refbook
+
+import (
+	"reflect"
+	"testing"
+)
+
+func itemIDs(items []Item) []int {
+	res := make([]int, len(items))
+	for i := range items {
+		res[i] = items[i].ID
+	}
+	return res
+}
+
+func TestBook_Order(t *testing.T) {
+
+	src := []byte(`[{"id":1,"name":"Post"},{"id":2,"name":"Ölhandel"},{"id":3,"name":"Apfel"},{"id":4,"name":"Obst"}]`)
+
+	tc := []struct {
+		name     string
+		order    Order
+		expected []int
+	}{
+		{"insertion", OrderInsertion, []int{1, 2, 3, 4}},
+		{"name", OrderName, []int{3, 4, 2, 1}},
+		{"sort order", OrderSortOrder, []int{3, 4, 2, 1}},
+	}
+
+	for i := range tc {
+		t.Run(tc[i].name, func(t *testing.T) {
+			b := NewConcurrentBook(WithDefaultLang("de"), WithOrder(tc[i].order))
+			if err := b.Parse(src); err != nil {
+				t.Fatal(err)
+			}
+
+			if res := itemIDs(b.Items()); !reflect.DeepEqual(res, tc[i].expected) {
+				t.Errorf("expected %v, got %v", tc[i].expected, res)
+			}
+
+			var res []int
+			b.Traverse(func(id int, name string) bool {
+				res = append(res, id)
+				return true
+			})
+			if !reflect.DeepEqual(res, tc[i].expected) {
+				t.Errorf("expected %v, got %v", tc[i].expected, res)
+			}
+		})
+	}
+}
+
+func TestBook_SortOrder(t *testing.T) {
+
+	src := []byte(`[{"id":1,"name":"A","sort_order":3},{"id":2,"name":"B","sort_order":1},{"id":3,"name":"C","sort_order":2},{"id":4,"name":"AA","sort_order":2}]`)
+
+	b := NewConcurrentBook(WithOrder(OrderSortOrder))
+	if err := b.Parse(src); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"items":[{"id":2,"name":"B","sort_order":1},{"id":4,"name":"AA","sort_order":2},{"id":3,"name":"C","sort_order":2},{"id":1,"name":"A","sort_order":3}],"hash":"`
+	if res := string(b.JSON()); len(res) < len(expected) || res[:len(expected)] != expected {
+		t.Errorf("unexpected JSON %s", res)
+	}
+
+	// sort order is kept, name defines order among equal ones.
+	b.Set(3, "0")
+	b.Set(5, "E")
+	if res, expected := itemIDs(b.Items()), []int{5, 2, 3, 4, 1}; !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected %v, got %v", expected, res)
+	}
+
+	if err := b.Replace([]Item{{ID: 1, Name: "A", SortOrder: 1}, {ID: 2, Name: "B", SortOrder: 0}}); err != nil {
+		t.Fatal(err)
+	}
+	if res, expected := itemIDs(b.Items()), []int{2, 1}; !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected %v, got %v", expected, res)
+	}
+}
+
+func TestBook_HashIndependentOfOrder(t *testing.T) {
+
+	a := NewBook()
+	if err := a.Parse([]byte(`[{"id":1,"name":"B"},{"id":2,"name":"A"}]`)); err != nil {
+		t.Fatal(err)
+	}
+
+	b := NewBook(WithOrder(OrderName))
+	if err := b.Parse([]byte(`[{"id":2,"name":"A"},{"id":1,"name":"B"}]`)); err != nil {
+		t.Fatal(err)
+	}
+
+	if a.Hash() != b.Hash() {
+		t.Errorf("expected equal hashes, got %d and %d", a.Hash(), b.Hash())
+	}
+
+	// sort order is a part of the item.
+	c := NewBook()
+	if err := c.Parse([]byte(`[{"id":1,"name":"B","sort_order":1},{"id":2,"name":"A"}]`)); err != nil {
+		t.Fatal(err)
+	}
+	if a.Hash() == c.Hash() {
+		t.Error("expected different hashes")
+	}
+
+	// attributes belong to their ids.
+	tcs := [][2]string{
+		{`[{"id":1,"name":"A"},{"id":2,"name":"B"}]`, `[{"id":1,"name":"B"},{"id":2,"name":"A"}]`},
+		{`[{"id":1,"name":"A","sort_order":1},{"id":2,"name":"B","sort_order":2}]`, `[{"id":1,"name":"A","sort_order":2},{"id":2,"name":"B","sort_order":1}]`},
+		{`[{"id":1,"name":"A"},{"id":2,"name":"B","is_active":false}]`, `[{"id":1,"name":"A","is_active":false},{"id":2,"name":"B"}]`},
+	}
+	for _, tc := range tcs {
+		x, y := NewBook(), NewBook()
+		if err := x.Parse([]byte(tc[0])); err != nil {
+			t.Fatal(err)
+		}
+		if err := y.Parse([]byte(tc[1])); err != nil {
+			t.Fatal(err)
+		}
+		if x.Hash() == y.Hash() {
+			t.Errorf("expected different hashes of %s and %s", tc[0], tc[1])
+		}
+	}
+}
+
+func TestFlexBook_Order(t *testing.T) {
+
+	b := NewFlexBook(WithThreadSafe(), WithDefaultLang("en"), WithOrder(OrderName))
+	err := b.Replace([]MultiLangItem{
+		{ID: 1, Name: map[string]string{"en": "Cherry", "ru": "Вишня"}},
+		{ID: 2, Name: map[string]string{"en": "Apple", "ru": "Яблоко"}},
+		{ID: 3, Name: map[string]string{"en": "Banana", "ru": "Банан"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res, expected := itemIDs(b.Items("en")), []int{2, 3, 1}; !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected %v, got %v", expected, res)
+	}
+	if res, expected := itemIDs(b.Items("ru")), []int{3, 1, 2}; !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected %v, got %v", expected, res)
+	}
+
+	b.AddMultiLangItem(MultiLangItem{ID: 4, Name: map[string]string{"en": "Apricot", "ru": "Абрикос"}})
+	if res, expected := itemIDs(b.Items("ru")), []int{4, 3, 1, 2}; !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected %v, got %v", expected, res)
+	}
+
+	if err := b.Optimize(); err != nil {
+		t.Fatal(err)
+	}
+	var buf []byte
+	b.BookAsJSON("en", &buf)
+	expected := `{"items":[{"id":2,"name":"Apple"},{"id":4,"name":"Apricot"},{"id":3,"name":"Banana"},{"id":1,"name":"Cherry"}]`
+	if len(buf) < len(expected) || string(buf[:len(expected)]) != expected {
+		t.Errorf("unexpected JSON %s", buf)
+	}
+}
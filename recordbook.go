@@ -0,0 +1,182 @@
+package refbook
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// RecordBook implements in-memory storage of reference book which keeps
+// complete source records of type T, not only id and name.
+// T has to be a struct, attributes of id and name are given by names
+// of the struct fields.
+//
+// JSON output includes all fields of T marshaled according to T's tags.
+type RecordBook[K comparable, T any] struct {
+	BookOf[K, T]
+	idAttr     string
+	nameAttr   string
+	indexAttrs []string
+
+	mux sync.Mutex // serializes index rebuilding.
+	idx atomic.Pointer[recordIndex[K, T]]
+}
+
+// recordIndex keeps secondary indexes built for the book snapshot.
+type recordIndex[K comparable, T any] struct {
+	snap   *bookSnapshot[K, T]
+	ver    uint64
+	byAttr map[string]map[interface{}]K
+}
+
+// NewRecordBook returns new instance of concurrent unsafe RecordBook.
+// Attributes indexAttrs can be used for lookups by method By.
+// Panics if T has no fields given by attribute names.
+func NewRecordBook[K comparable, T any](idAttr, nameAttr string, indexAttrs ...string) *RecordBook[K, T] {
+	b := RecordBook[K, T]{idAttr: idAttr, nameAttr: nameAttr, indexAttrs: indexAttrs}
+	b.init(false)
+	return &b
+}
+
+// NewConcurrentRecordBook returns new instance of concurrent safe RecordBook.
+func NewConcurrentRecordBook[K comparable, T any](idAttr, nameAttr string, indexAttrs ...string) *RecordBook[K, T] {
+	b := RecordBook[K, T]{idAttr: idAttr, nameAttr: nameAttr, indexAttrs: indexAttrs}
+	b.init(true)
+	return &b
+}
+
+func (b *RecordBook[K, T]) init(isConcurrent bool) {
+
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() != reflect.Struct {
+		panic("refbook: record type " + t.String() + " is not a struct")
+	}
+
+	for _, attr := range append([]string{b.idAttr, b.nameAttr}, b.indexAttrs...) {
+		f, ok := t.FieldByName(attr)
+		if !ok {
+			panic(fmt.Sprintf("refbook: attribute %s not found in %s", attr, t))
+		}
+		if !f.Type.Comparable() {
+			panic(fmt.Sprintf("refbook: attribute %s of %s is not comparable", attr, t))
+		}
+	}
+
+	b.BookOf.init(Option{isConcurrent: isConcurrent})
+	b.nameOf = func(v T) string {
+		return defaultName(reflect.ValueOf(v).FieldByName(b.nameAttr).Interface())
+	}
+	b.rowOf = func(item ItemOf[K, T]) interface{} {
+		return item.Name
+	}
+}
+
+func (b *RecordBook[K, T]) items(records []T) ([]ItemOf[K, T], error) {
+	items := make([]ItemOf[K, T], len(records))
+	for i := range records {
+		id, err := fieldAs[K](reflect.ValueOf(records[i]).FieldByName(b.idAttr))
+		if err != nil {
+			return nil, err
+		}
+		items[i] = ItemOf[K, T]{ID: id, Name: records[i]}
+	}
+	return items, nil
+}
+
+// Set inserts/update reference book record.
+func (b *RecordBook[K, T]) Set(record T) error {
+	items, err := b.items([]T{record})
+	if err != nil {
+		return err
+	}
+	b.BookOf.Set(items[0].ID, record)
+	return nil
+}
+
+// LoadFromSlice replaces all records of the book.
+func (b *RecordBook[K, T]) LoadFromSlice(records []T) error {
+	items, err := b.items(records)
+	if err != nil {
+		return err
+	}
+	return b.load(items)
+}
+
+// Replace replaces all records of the book at once.
+func (b *RecordBook[K, T]) Replace(records []T) error {
+	return b.LoadFromSlice(records)
+}
+
+// Parse parses JSON array of records and replaces all records of the book.
+func (b *RecordBook[K, T]) Parse(buf []byte) error {
+	var records []T
+	if err := json.Unmarshal(buf, &records); err != nil {
+		return err
+	}
+	return b.LoadFromSlice(records)
+}
+
+// MarshalJSON implements interface json.Marshaler.
+func (b *RecordBook[K, T]) MarshalJSON() ([]byte, error) {
+	return b.JSON(), nil
+}
+
+// index returns secondary indexes of the current snapshot.
+func (b *RecordBook[K, T]) index() *recordIndex[K, T] {
+	s := b.snapshot()
+	if idx := b.idx.Load(); idx != nil && idx.snap == s && idx.ver == s.ver {
+		return idx
+	}
+
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	s = b.snapshot()
+	if idx := b.idx.Load(); idx != nil && idx.snap == s && idx.ver == s.ver {
+		return idx
+	}
+
+	idx := recordIndex[K, T]{snap: s, ver: s.ver, byAttr: make(map[string]map[interface{}]K, len(b.indexAttrs))}
+	for _, attr := range b.indexAttrs {
+		m := make(map[interface{}]K, len(s.jsonInput.Items))
+		// reverse order keeps the first record if values are not unique.
+		for i := len(s.jsonInput.Items) - 1; i >= 0; i-- {
+			item := &s.jsonInput.Items[i]
+			m[reflect.ValueOf(item.Name).FieldByName(attr).Interface()] = item.ID
+		}
+		idx.byAttr[attr] = m
+	}
+	b.idx.Store(&idx)
+	return &idx
+}
+
+// By returns the record which attribute attr is equal to value.
+// Attribute has to be listed in indexAttrs of the constructor. If several
+// records have the same value, the first loaded is returned.
+//
+//	et.By("Code", "CONLOST")
+func (b *RecordBook[K, T]) By(attr string, value interface{}) (T, bool) {
+	var res T
+
+	m, ok := b.index().byAttr[attr]
+	if !ok || value == nil {
+		return res, false
+	}
+
+	// value can be given as underlying type of the attribute.
+	f, _ := reflect.TypeOf((*T)(nil)).Elem().FieldByName(attr)
+	if v := reflect.ValueOf(value); v.Type() != f.Type {
+		if !convertible(v.Type(), f.Type) {
+			return res, false
+		}
+		value = v.Convert(f.Type).Interface()
+	}
+
+	id, ok := m[value]
+	if !ok {
+		return res, false
+	}
+	return b.Get(id)
+}
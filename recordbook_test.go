@@ -0,0 +1,112 @@
+package refbook
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type testEventCode string
+
+type testEventType struct {
+	ID         int           `json:"id"`
+	Code       testEventCode `json:"code"`
+	Name       string        `json:"name"`
+	IsCritical bool          `json:"is_critical"`
+}
+
+func TestRecordBook(t *testing.T) {
+
+	ets := []testEventType{
+		{1, "CONLOST", "Connection Lost", true},
+		{2, "SERVERUP", "Server up", false},
+	}
+
+	b := NewConcurrentRecordBook[int, testEventType]("ID", "Name", "Code", "IsCritical")
+	if err := b.LoadFromSlice(ets); err != nil {
+		t.Fatal(err)
+	}
+
+	if name := b.Name(0, 2); name != "Server up" {
+		t.Errorf("expected Server up, got %s", name)
+	}
+
+	et, ok := b.Get(1)
+	if !ok || !et.IsCritical || et.Code != "CONLOST" {
+		t.Errorf("unexpected record %v", et)
+	}
+
+	et, ok = b.By("Code", "SERVERUP")
+	if !ok || et.ID != 2 {
+		t.Errorf("unexpected record %v", et)
+	}
+
+	et, ok = b.By("IsCritical", true)
+	if !ok || et.ID != 1 {
+		t.Errorf("unexpected record %v", et)
+	}
+
+	if _, ok := b.By("Code", "UNKNOWN"); ok {
+		t.Error("unexpected record")
+	}
+	if _, ok := b.By("Name", "Server up"); ok {
+		t.Error("Name is not indexed")
+	}
+	if _, ok := b.By("Code", 1); ok {
+		t.Error("int can't match code")
+	}
+
+	// index follows changes.
+	if err := b.Set(testEventType{3, "DISKFULL", "Disk full", true}); err != nil {
+		t.Fatal(err)
+	}
+	if et, ok := b.By("Code", testEventCode("DISKFULL")); !ok || et.ID != 3 {
+		t.Errorf("unexpected record %v", et)
+	}
+
+	var ids []int
+	b.Contains("disk", &ids)
+	if len(ids) != 1 || ids[0] != 3 {
+		t.Errorf("expected [3], got %v", ids)
+	}
+
+	if err := b.Optimize(); err != nil {
+		t.Fatal(err)
+	}
+
+	var res struct {
+		Items []map[string]interface{} `json:"items"`
+		Hash  string                   `json:"hash"`
+	}
+	if err := json.Unmarshal(b.JSON(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Items) != 3 || res.Items[0]["code"] != "CONLOST" || res.Items[0]["is_critical"] != true || res.Hash == "" {
+		t.Errorf("unexpected JSON %s", b.JSON())
+	}
+}
+
+func TestRecordBook_Parse(t *testing.T) {
+
+	b := NewRecordBook[string, testEventType]("Code", "Name", "ID")
+	src := []byte(`[{"id":1,"code":"CONLOST","name":"Connection Lost","is_critical":true}]`)
+	if err := b.Parse(src); err != nil {
+		t.Fatal(err)
+	}
+
+	if name := b.Name(0, "CONLOST"); name != "Connection Lost" {
+		t.Errorf("expected Connection Lost, got %s", name)
+	}
+	if et, ok := b.By("ID", 1); !ok || et.Code != "CONLOST" {
+		t.Errorf("unexpected record %v", et)
+	}
+}
+
+func TestNewRecordBook_Panic(t *testing.T) {
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic")
+		}
+	}()
+	NewRecordBook[int, testEventType]("ID", "Title")
+}
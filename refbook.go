@@ -1,34 +1,213 @@
 package refbook
 
-import "sync"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
 
 var (
 	// NotFoundName returns by Name() if key not found.
 	NotFoundName = "?"
 
+	// ErrReadOnly returns by methods changing the language book of FlexBook,
+	// see FlexBook.Book.
+	ErrReadOnly = errors.New("read only book")
+
+	// ErrNameNotFound returns by IDByName if no item has the name.
+	ErrNameNotFound = errors.New("name not found")
+
+	// ErrAmbiguousName returns by IDByName if several items have the name.
+	ErrAmbiguousName = errors.New("name is ambiguous")
+
 	mux             sync.RWMutex
 	defaultLangCode LangCode = ToLangCode("en")
 )
 
-type LangCode uint16
+// ItemOf describes JSON unmarshal destination for single language reference
+// table with keys of type K and payloads of type V.
+// Inactive item is retired, it's not offered for new input, see
+// ActiveItems. In JSON it's represented by "is_active":false.
+//
+// Item having validity interval [ValidFrom, ValidTo) is a version of the
+// item, the book keeps all versions of the item, see NameAt.
+//
+// Aliases are alternative names matched by Contains, IDByName and Search,
+// for instance "USA" and "America" for "United States".
+type ItemOf[K comparable, V any] struct {
+	ID        K         `json:"id"`
+	Name      V         `json:"name"`
+	Aliases   []string  `json:"aliases,omitempty"`
+	SortOrder int       `json:"sort_order,omitempty"` // see WithOrder.
+	Inactive  bool      `json:"-"`
+	ValidFrom time.Time `json:"-"` // zero means unbounded.
+	ValidTo   time.Time `json:"-"` // zero means unbounded.
+}
 
-// Item describes JSON unmarshal destination for single language reference table.
-type Item struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
+// itemJSON is JSON representation of ItemOf.
+type itemJSON[K comparable, V any] struct {
+	ID   K `json:"id"`
+	Name V `json:"name"`
+	attrsJSON
 }
 
+// attrsJSON is JSON representation of attributes of ItemOf other than
+// id and name.
+type attrsJSON struct {
+	Aliases   []string  `json:"aliases,omitempty"`
+	SortOrder int       `json:"sort_order,omitempty"`
+	IsActive  *bool     `json:"is_active,omitempty"` // omitted if item is active.
+	ValidFrom *jsonTime `json:"valid_from,omitempty"`
+	ValidTo   *jsonTime `json:"valid_to,omitempty"`
+}
+
+// jsonTime is time in JSON given as RFC 3339 or as date "2006-01-02".
+type jsonTime time.Time
+
+func (t jsonTime) MarshalJSON() ([]byte, error) {
+	return time.Time(t).MarshalJSON()
+}
+
+func (t *jsonTime) UnmarshalJSON(buf []byte) error {
+	var s string
+	if err := json.Unmarshal(buf, &s); err != nil {
+		return err
+	}
+	v, err := parseTime(s)
+	if err != nil {
+		return err
+	}
+	*t = jsonTime(v)
+	return nil
+}
+
+// parseTime parses time given as RFC 3339 or as date "2006-01-02".
+func parseTime(s string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339Nano, time.DateOnly} {
+		if v, err := time.Parse(layout, s); err == nil {
+			return v, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", s)
+}
+
+// validate checks validity interval of the item, see checkPeriods.
+func (item *ItemOf[K, V]) validate() error {
+	if !item.ValidFrom.IsZero() && !item.ValidTo.IsZero() && !item.ValidTo.After(item.ValidFrom) {
+		return errors.New("valid_to is not after valid_from")
+	}
+	return nil
+}
+
+// toJSONTime returns nil if t is zero.
+func toJSONTime(t time.Time) *jsonTime {
+	if t.IsZero() {
+		return nil
+	}
+	jt := jsonTime(t)
+	return &jt
+}
+
+// fromJSONTime returns zero time if t is nil.
+func fromJSONTime(t *jsonTime) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return time.Time(*t)
+}
+
+// MarshalJSON implements interface json.Marshaler.
+func (item ItemOf[K, V]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(itemJSON[K, V]{ID: item.ID, Name: item.Name, attrsJSON: item.attrs()})
+}
+
+// attrs returns JSON representation of attributes other than id and name.
+func (item *ItemOf[K, V]) attrs() attrsJSON {
+	a := attrsJSON{
+		Aliases:   item.Aliases,
+		SortOrder: item.SortOrder,
+		ValidFrom: toJSONTime(item.ValidFrom),
+		ValidTo:   toJSONTime(item.ValidTo),
+	}
+	if item.Inactive {
+		a.IsActive = new(bool)
+	}
+	return a
+}
+
+// UnmarshalJSON implements interface json.Unmarshaler. Item is active if
+// "is_active" is missing.
+func (item *ItemOf[K, V]) UnmarshalJSON(buf []byte) error {
+	var j itemJSON[K, V]
+	if err := json.Unmarshal(buf, &j); err != nil {
+		return err
+	}
+	*item = ItemOf[K, V]{
+		ID:        j.ID,
+		Name:      j.Name,
+		Aliases:   j.Aliases,
+		SortOrder: j.SortOrder,
+		Inactive:  j.IsActive != nil && !*j.IsActive,
+		ValidFrom: fromJSONTime(j.ValidFrom),
+		ValidTo:   fromJSONTime(j.ValidTo),
+	}
+	return nil
+}
+
+// Item describes JSON unmarshal destination for single language reference table.
+type Item = ItemOf[int, string]
+
 // MultiLangItem describes JSON unmarshal destination for multi language reference table.
+// Keys of Name and Aliases are BCP 47 language tags.
 type MultiLangItem struct {
-	ID   int               `json:"id"`
-	Name map[string]string `json:"name"`
+	ID        int                 `json:"id"`
+	Name      map[string]string   `json:"name"`
+	Aliases   map[string][]string `json:"aliases,omitempty"`
+	SortOrder int                 `json:"sort_order,omitempty"`
+	Inactive  bool                `json:"-"`
+	ValidFrom time.Time           `json:"-"`
+	ValidTo   time.Time           `json:"-"`
+}
+
+// UnmarshalJSON implements interface json.Unmarshaler. Item is active if
+// "is_active" is missing.
+func (item *MultiLangItem) UnmarshalJSON(buf []byte) error {
+	type plain MultiLangItem
+	var j struct {
+		plain
+		IsActive  *bool     `json:"is_active"`
+		ValidFrom *jsonTime `json:"valid_from"`
+		ValidTo   *jsonTime `json:"valid_to"`
+	}
+	if err := json.Unmarshal(buf, &j); err != nil {
+		return err
+	}
+	*item = MultiLangItem(j.plain)
+	item.Inactive = j.IsActive != nil && !*j.IsActive
+	item.ValidFrom = fromJSONTime(j.ValidFrom)
+	item.ValidTo = fromJSONTime(j.ValidTo)
+	return nil
+}
+
+// validate checks validity interval of the item read by ParseCSV.
+func (item *MultiLangItem) validate() error {
+	i := item.item("", nil)
+	return i.validate()
 }
 
-func ToLangCode(src string) LangCode {
-	if len(src) == 0 || len(src) > 2 {
-		return 0 // default language
+// item returns the item of the language book having the name and aliases.
+func (item *MultiLangItem) item(name string, aliases []string) Item {
+	return Item{
+		ID:        item.ID,
+		Name:      name,
+		Aliases:   aliases,
+		SortOrder: item.SortOrder,
+		Inactive:  item.Inactive,
+		ValidFrom: item.ValidFrom,
+		ValidTo:   item.ValidTo,
 	}
-	return LangCode(uint16(src[0])<<8 | uint16(src[1]))
 }
 
 // SetDefaultLang changes default language.
@@ -3,6 +3,7 @@ package refbook
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,9 +14,9 @@ import (
 func TestRefBook_WriteJSON(t *testing.T) {
 
 	rb := New()
-	rb.list = append(rb.list, Item{1, "A"})
-	rb.list = append(rb.list, Item{2, "B"})
-	rb.list = append(rb.list, Item{3, "C"})
+	rb.list = append(rb.list, Item{ID: 1, Name: "A"})
+	rb.list = append(rb.list, Item{ID: 2, Name: "B"})
+	rb.list = append(rb.list, Item{ID: 3, Name: "C"})
 	rb.h = calcHash(rb.list)
 
 	buf := bytes.NewBuffer(nil)
@@ -30,7 +31,7 @@ func TestRefBook_WriteJSON(t *testing.T) {
 
 func TestRefBook_Name(t *testing.T) {
 
-	list := []Item{{1, "A"}, {2, "B"}, {3, "C"}}
+	list := []Item{{ID: 1, Name: "A"}, {ID: 2, Name: "B"}, {ID: 3, Name: "C"}}
 	rb := New()
 
 	rb.list = append(rb.list, list...)
@@ -249,8 +250,8 @@ func TestFlexLangBook_LoadFromSlice(t *testing.T) {
 	}
 
 	t.Log(b.Len())
-	t.Logf("%v\n", b.book[0].jsonInput.Items)
-	t.Logf("%v\n", b.book[1].jsonInput.Items)
+	t.Logf("%v\n", b.books.Load().book[0].snapshot().jsonInput.Items)
+	t.Logf("%v\n", b.books.Load().book[1].snapshot().jsonInput.Items)
 
 	for i := range src {
 		if !b.IsExist(src[i].ID) {
@@ -318,12 +319,12 @@ func TestBook_Parse(t *testing.T) {
 
 	src := []byte(`[{"id":1,"name":"A"},{"id":2,"name":"B"},{"id":3,"name":"C"},{"id":4,"name":"D"},{"id":5,"name":"E"}, {"id":6}]`)
 	tc := []Item{
-		{1, "A"},
-		{2, "B"},
-		{3, "C"},
-		{4, "D"},
-		{5, "E"},
-		{6, ""},
+		{ID: 1, Name: "A"},
+		{ID: 2, Name: "B"},
+		{ID: 3, Name: "C"},
+		{ID: 4, Name: "D"},
+		{ID: 5, Name: "E"},
+		{ID: 6, Name: ""},
 	}
 
 	b := NewFlexBook()
@@ -352,18 +353,18 @@ func TestFlexBook(t *testing.T) {
 		row      MultiLangItem
 		expected map[string]string
 	}{
-		{MultiLangItem{1, map[string]string{"en": "Hello", "ru": "Привет"}}, map[string]string{"en": "Hello"}},
-		{MultiLangItem{2, map[string]string{"en": "World"}}, map[string]string{"en": "World"}},
-		{MultiLangItem{3, map[string]string{"ru": "Гоу"}}, map[string]string{"en": NotFoundName}},
-		{MultiLangItem{4, map[string]string{}}, map[string]string{"en": NotFoundName, "ru": NotFoundName}},
+		{MultiLangItem{ID: 1, Name: map[string]string{"en": "Hello", "ru": "Привет"}}, map[string]string{"en": "Hello"}},
+		{MultiLangItem{ID: 2, Name: map[string]string{"en": "World"}}, map[string]string{"en": "World"}},
+		{MultiLangItem{ID: 3, Name: map[string]string{"ru": "Гоу"}}, map[string]string{"en": NotFoundName}},
+		{MultiLangItem{ID: 4, Name: map[string]string{}}, map[string]string{"en": NotFoundName, "ru": NotFoundName}},
 	}
 
 	for i := range rows {
 		mb.AddMultiLangItem(rows[i].row)
 	}
 
-	fmt.Println(mb.book[0].m, mb.book[1].m)
-	fmt.Println(mb.book[0].uItems, mb.book[1].uItems)
+	fmt.Println(mb.books.Load().book[0].snapshot().m, mb.books.Load().book[1].snapshot().m)
+	fmt.Println(mb.books.Load().book[0].snapshot().uItems, mb.books.Load().book[1].snapshot().uItems)
 
 	for i := range rows {
 		if !mb.IsExist(rows[i].row.ID) {
@@ -383,12 +384,12 @@ func TestFlexBook_Parse(t *testing.T) {
 
 	src := []byte(`[{"id":1,"name":"A"},{"id":2,"name":"B"},{"id":3,"name":"C"},{"id":4,"name":"D"},{"id":5,"name":"E"}, {"id":6}]`)
 	tc := []Item{
-		{1, "A"},
-		{2, "B"},
-		{3, "C"},
-		{4, "D"},
-		{5, "E"},
-		{6, ""},
+		{ID: 1, Name: "A"},
+		{ID: 2, Name: "B"},
+		{ID: 3, Name: "C"},
+		{ID: 4, Name: "D"},
+		{ID: 5, Name: "E"},
+		{ID: 6, Name: ""},
 	}
 
 	b := NewFlexBook()
@@ -414,10 +415,10 @@ func TestFlexBook_ParseMultiLang(t *testing.T) {
 	src := []byte(`[{"id":1,"name":{"en":"A","ru":"AA"}},
 	{"id":2,"name":{"en":"B"}},{"id":3,"name":{"ru":"CC"}},{"id":4}]`)
 	tc := []Item{
-		{1, "A"},
-		{2, "B"},
-		{3, NotFoundName},
-		{4, NotFoundName},
+		{ID: 1, Name: "A"},
+		{ID: 2, Name: "B"},
+		{ID: 3, Name: NotFoundName},
+		{ID: 4, Name: NotFoundName},
 	}
 
 	b := NewFlexBook(WithThreadSafe(), WithDefaultLang("en"))
@@ -438,3 +439,219 @@ func TestFlexBook_ParseMultiLang(t *testing.T) {
 		})
 	}
 }
+
+func TestFlexBook_Delete(t *testing.T) {
+
+	b := NewFlexBook(WithThreadSafe(), WithDefaultLang("en"))
+	src := []byte(`[{"id":1,"name":{"en":"A","ru":"AA"}},{"id":2,"name":{"en":"B","ru":"BB"}}]`)
+	if err := b.Parse(src); err != nil {
+		t.Fatal(err)
+	}
+
+	b.Delete(1)
+	b.Delete(3)
+
+	if b.IsExist(1) || b.Len() != 1 {
+		t.Error("item 1 not deleted")
+	}
+
+	for _, lang := range []string{"en", "ru"} {
+		bk := b.Book(lang)
+		if bk.IsExist(1) || bk.Len() != 1 {
+			t.Errorf("lang %s: item 1 not deleted", lang)
+		}
+		if name := b.Name(ToLangCode(lang), 1); name != NotFoundName {
+			t.Errorf("lang %s: expected NotFoundName, got %s", lang, name)
+		}
+	}
+}
+
+func TestFlexBook_Replace(t *testing.T) {
+
+	b := NewFlexBook(WithThreadSafe(), WithDefaultLang("en"))
+	b.AddMultiLangItem(MultiLangItem{ID: 1, Name: map[string]string{"en": "A", "ru": "AA"}})
+
+	ru := b.Book("ru")
+	err := b.Replace([]MultiLangItem{
+		{ID: 2, Name: map[string]string{"en": "B", "de": "BBB"}},
+		{ID: 3, Name: map[string]string{"en": "C"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b.IsExist(1) || b.Len() != 2 {
+		t.Error("unexpected content")
+	}
+	if name := b.Name(ToLangCode("de"), 2); name != "BBB" {
+		t.Errorf("expected BBB, got %s", name)
+	}
+	if b.Book("ru") != b.Book("en") {
+		t.Error("language ru expected to be removed")
+	}
+	if !ru.IsExist(1) {
+		t.Error("published book has been modified")
+	}
+	if b.Hash("de") == 0 {
+		t.Error("expected optimized book")
+	}
+
+	if err := b.ReplaceItems([]Item{{ID: 5, Name: "E"}}); err != nil {
+		t.Fatal(err)
+	}
+	if name := b.Name(0, 5); name != "E" || b.Len() != 1 {
+		t.Errorf("expected E, got %s", name)
+	}
+}
+
+func TestFlexBook_AutoOptimize(t *testing.T) {
+
+	b := NewFlexBook(WithThreadSafe(), WithDefaultLang("en"), WithAutoOptimize(0))
+	b.AddMultiLangItem(MultiLangItem{ID: 1, Name: map[string]string{"en": "A", "ru": "AA"}})
+
+	var buf []byte
+	b.BookAsJSON("ru", &buf)
+	if string(buf) != `{"items":[{"id":1,"name":"AA"}],"hash":"`+strconv.FormatUint(b.Hash("ru"), 10)+`"}` {
+		t.Errorf("unexpected JSON %s", buf)
+	}
+
+	b.AddMultiLangItem(MultiLangItem{ID: 2, Name: map[string]string{"en": "B", "ru": "BB"}})
+	if b.Hash("en") == 0 || b.Hash("ru") == 0 {
+		t.Error("expected hash")
+	}
+	if len(b.Book("en").JSON()) == 0 {
+		t.Error("expected JSON")
+	}
+
+	d := NewFlexBook(WithThreadSafe(), WithAutoOptimize(10*time.Millisecond))
+	d.AddItem(Item{ID: 1, Name: "A"})
+	waitFor(t, "optimization in background", func() bool {
+		return !d.books.Load().isCompileRequired()
+	})
+}
+
+func TestFlexBook_LangFallback(t *testing.T) {
+
+	b := NewFlexBook(WithDefaultLang("en"), WithLangFallback("kk", "ru"), WithLangFallback("be", "ru", "en"))
+	b.AddMultiLangItems([]MultiLangItem{
+		{ID: 1, Name: map[string]string{"en": "Individual", "ru": "Физлицо", "kk": "Жеке тұлға"}},
+		{ID: 2, Name: map[string]string{"en": "Organization", "ru": "Организация"}},
+		{ID: 3, Name: map[string]string{"en": "Other"}},
+	})
+	if err := b.Optimize(); err != nil {
+		t.Fatal(err)
+	}
+
+	tc := []struct {
+		lang     string
+		id       int
+		expected string
+	}{
+		{"kk", 1, "Жеке тұлға"},
+		{"kk", 2, "Организация"},
+		{"kk", 3, "Other"},
+		{"be", 1, "Физлицо"},
+		{"be", 2, "Организация"},
+		{"de", 2, "Organization"},
+		{"ru", 3, "Other"},
+		{"kk", 4, NotFoundName},
+	}
+
+	for i := range tc {
+		t.Run(tc[i].lang+strconv.Itoa(tc[i].id), func(t *testing.T) {
+			if name := b.Name(ToLangCode(tc[i].lang), tc[i].id); name != tc[i].expected {
+				t.Errorf("expected %s, got %s", tc[i].expected, name)
+			}
+		})
+	}
+
+	// book be is missing, ru is served.
+	if b.Book("be") != b.Book("ru") {
+		t.Error("expected book ru for be")
+	}
+	var be, ru []byte
+	b.BookAsJSON("be", &be)
+	b.BookAsJSON("ru", &ru)
+	if string(be) != string(ru) {
+		t.Errorf("expected %s, got %s", ru, be)
+	}
+	if b.Book("kk").Name(0, 2) != "Организация" {
+		t.Error("missing kk name expected to be taken from ru")
+	}
+	if b.Book("de") != b.Book("en") {
+		t.Error("expected default book for unknown language")
+	}
+}
+
+func TestFlexBook_NewLangFill(t *testing.T) {
+
+	b := NewFlexBook(WithDefaultLang("en"), WithLangFallback("kk", "ru"))
+	b.AddMultiLangItem(MultiLangItem{ID: 1, Name: map[string]string{"en": "Hello"}})
+	b.AddMultiLangItem(MultiLangItem{ID: 2, Name: map[string]string{"en": "World", "de": "Welt"}})
+
+	if n := b.Book("de").Len(); n != 2 {
+		t.Errorf("expected 2 items in de, got %d", n)
+	}
+	if name := b.Book("de").Name(0, 1); name != "Hello" {
+		t.Errorf("expected Hello, got %s", name)
+	}
+	if err := b.Optimize(); err != nil {
+		t.Fatal(err)
+	}
+	var de []byte
+	b.BookAsJSON("de", &de)
+	if !strings.Contains(string(de), "Hello") || !strings.Contains(string(de), "Welt") {
+		t.Errorf("expected both items in de, got %s", de)
+	}
+
+	// names are taken by fallback chain of the new language.
+	b.AddMultiLangItem(MultiLangItem{ID: 3, Name: map[string]string{"en": "Person", "ru": "Лицо"}})
+	b.AddMultiLangItem(MultiLangItem{ID: 4, Name: map[string]string{"en": "Kazakh", "kk": "Қазақ"}})
+	kk := b.Book("kk")
+	if kk.Len() != 4 || kk.Name(0, 1) != "Hello" || kk.Name(0, 3) != "Лицо" || kk.Name(0, 4) != "Қазақ" {
+		t.Errorf("unexpected kk items %v", kk.Items())
+	}
+}
+
+func TestFlexBook_IDByName(t *testing.T) {
+
+	b := NewFlexBook(WithThreadSafe(), WithDefaultLang("en"))
+	b.AddMultiLangItems([]MultiLangItem{
+		{ID: 1, Name: map[string]string{"en": "Individual", "ru": "Физлицо"}},
+		{ID: 2, Name: map[string]string{"en": "Organization", "ru": "Организация"}},
+	})
+
+	if id, err := b.IDByName(ToLangCode("ru"), "организация", MatchCaseInsensitive); err != nil || id != 2 {
+		t.Errorf("expected 2, got %d %v", id, err)
+	}
+	if _, err := b.IDByName(ToLangCode("en"), "Организация", MatchExact); err != ErrNameNotFound {
+		t.Error("name in other language must not be found")
+	}
+	if id, err := b.IDByName(ToLangCode("ru-RU"), "Физлицо", MatchExact); err != nil || id != 1 {
+		t.Errorf("expected 1, got %d %v", id, err)
+	}
+
+	b.AddMultiLangItem(MultiLangItem{ID: 3, Name: map[string]string{"en": "Sole proprietor", "ru": "Физлицо"}})
+	if _, err := b.IDByName(ToLangCode("ru"), "физлицо", MatchCaseInsensitive); err != ErrAmbiguousName {
+		t.Errorf("expected ErrAmbiguousName, got %v", err)
+	}
+	if id, err := b.IDByName(ToLangCode("en"), "Sole proprietor", MatchExact); err != nil || id != 3 {
+		t.Errorf("expected 3, got %d %v", id, err)
+	}
+	// name copied to ru by fallback is not a ru name.
+	b.AddMultiLangItem(MultiLangItem{ID: 4, Name: map[string]string{"en": "World"}})
+	if _, err := b.IDByName(ToLangCode("ru"), "World", MatchExact); err != ErrNameNotFound {
+		t.Errorf("expected ErrNameNotFound, got %v", err)
+	}
+	b.AddMultiLangItem(MultiLangItem{ID: 4, Name: map[string]string{"en": "World", "ru": "Мир"}})
+	if id, err := b.IDByName(ToLangCode("ru"), "Мир", MatchExact); err != nil || id != 4 {
+		t.Errorf("expected 4, got %d %v", id, err)
+	}
+
+	// the same name given in ru later becomes a ru name.
+	b.AddMultiLangItem(MultiLangItem{ID: 5, Name: map[string]string{"en": "Moscow"}})
+	b.AddMultiLangItem(MultiLangItem{ID: 5, Name: map[string]string{"en": "Moscow", "ru": "Moscow"}})
+	if id, err := b.IDByName(ToLangCode("ru"), "Moscow", MatchExact); err != nil || id != 5 {
+		t.Errorf("expected 5, got %d %v", id, err)
+	}
+}
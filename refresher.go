@@ -0,0 +1,120 @@
+package refbook
+
+import (
+	"context"
+	"database/sql"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Refresher periodically reloads the book from its source. The book is
+// swapped and subscribers are notified only if the content has been
+// changed, what is detected by comparison of hashes.
+type Refresher struct {
+	b        *FlexBook
+	load     func(ctx context.Context, dst *FlexBook) error
+	interval time.Duration
+	jitter   time.Duration
+	onError  func(error)
+
+	mux  sync.Mutex
+	subs []func(b *FlexBook)
+}
+
+// WithJitter adds random duration from 0 to jitter to each refresh interval
+// of the Refresher. It spreads the load if many instances of the service
+// start at the same time.
+func WithJitter(jitter time.Duration) func(o *ReloaderOption) {
+	return func(o *ReloaderOption) {
+		o.jitter = jitter
+	}
+}
+
+// SQLSource returns source loading the book by FlexBook.LoadFromSQL from
+// the table given by WithTablename.
+func SQLSource(db *sql.DB, f ...func(*SQLOption)) func(ctx context.Context, dst *FlexBook) error {
+	return func(ctx context.Context, dst *FlexBook) error {
+		return dst.LoadFromSQL(ctx, db, "", f...)
+	}
+}
+
+// NewRefresher returns refresher of the book. Function load has to fill
+// empty book dst having the same default language and table name as b.
+// Options WithErrorHandler and WithJitter are applicable. Panics if
+// interval is not positive, like time.NewTicker does.
+func NewRefresher(b *FlexBook, load func(ctx context.Context, dst *FlexBook) error, interval time.Duration, f ...func(*ReloaderOption)) *Refresher {
+	if interval <= 0 {
+		panic("non-positive interval for NewRefresher")
+	}
+
+	o := ReloaderOption{onError: func(error) {}}
+	for i := range f {
+		f[i](&o)
+	}
+
+	return &Refresher{
+		b:        b,
+		load:     load,
+		interval: interval,
+		jitter:   o.jitter,
+		onError:  o.onError,
+	}
+}
+
+// Subscribe adds function called after the book has been changed.
+func (r *Refresher) Subscribe(f func(b *FlexBook)) {
+	r.mux.Lock()
+	r.subs = append(r.subs, f)
+	r.mux.Unlock()
+}
+
+// Run refreshes the book every interval until ctx is done.
+func (r *Refresher) Run(ctx context.Context) error {
+	for {
+		d := r.interval
+		if r.jitter > 0 {
+			d += time.Duration(rand.Int63n(int64(r.jitter)))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(d):
+		}
+
+		if _, err := r.Refresh(ctx); err != nil && ctx.Err() == nil {
+			r.onError(err)
+		}
+	}
+}
+
+// Refresh reloads the book once. Returns true if the book has been changed.
+func (r *Refresher) Refresh(ctx context.Context) (bool, error) {
+
+	dst := r.b.emptyCopy()
+	if err := r.load(ctx, dst); err != nil {
+		return false, err
+	}
+
+	if err := dst.Optimize(); err != nil {
+		return false, err
+	}
+
+	if dst.HashAll() == r.b.HashAll() {
+		return false, nil
+	}
+
+	if err := r.b.replace(dst.books.Load()); err != nil {
+		return false, err
+	}
+
+	r.mux.Lock()
+	subs := r.subs
+	r.mux.Unlock()
+
+	for _, f := range subs {
+		f(r.b)
+	}
+	return true, nil
+}
@@ -0,0 +1,152 @@
+package refbook
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRefresher(t *testing.T) {
+
+	db := openFakeDB(t)
+	defer db.Close()
+
+	setReloadTable("rf_a", "A1")
+
+	b := NewFlexBook(WithThreadSafe(), WithTablename("rf_a"))
+	r := NewRefresher(b, SQLSource(db), time.Hour)
+
+	var changes int
+	r.Subscribe(func(fb *FlexBook) {
+		if fb != b {
+			t.Error("unexpected book")
+		}
+		changes++
+	})
+
+	ctx := context.Background()
+	for i, tc := range []struct {
+		name    string
+		changed bool
+	}{
+		{"A1", true},
+		{"A1", false},
+		{"A2", true},
+	} {
+		setReloadTable("rf_a", tc.name)
+		books := b.books.Load()
+		changed, err := r.Refresh(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if changed != tc.changed {
+			t.Errorf("step %d: expected changed %v, got %v", i, tc.changed, changed)
+		}
+		if !changed && b.books.Load() != books {
+			t.Errorf("step %d: unchanged book must not be swapped", i)
+		}
+		if name := b.Name(0, 1); name != tc.name {
+			t.Errorf("step %d: expected %s, got %s", i, tc.name, name)
+		}
+	}
+
+	if changes != 2 {
+		t.Errorf("expected 2 notifications, got %d", changes)
+	}
+}
+
+func TestRefresher_Run(t *testing.T) {
+
+	var (
+		mux   sync.Mutex
+		name  = "A"
+		fail  = true
+		errs  int
+		loads int
+	)
+
+	load := func(ctx context.Context, dst *FlexBook) error {
+		mux.Lock()
+		defer mux.Unlock()
+		loads++
+		if fail {
+			fail = false
+			return errors.New("connection refused")
+		}
+		dst.AddItem(Item{ID: 1, Name: name})
+		return nil
+	}
+
+	b := NewFlexBook(WithThreadSafe())
+	r := NewRefresher(b, load, time.Millisecond,
+		WithJitter(time.Millisecond),
+		WithErrorHandler(func(error) {
+			mux.Lock()
+			errs++
+			mux.Unlock()
+		}))
+
+	changed := make(chan struct{}, 16)
+	r.Subscribe(func(*FlexBook) { changed <- struct{}{} })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- r.Run(ctx)
+	}()
+
+	<-changed
+	if b.Name(0, 1) != "A" {
+		t.Errorf("expected A, got %s", b.Name(0, 1))
+	}
+
+	// unchanged source does not notify.
+	mux.Lock()
+	n := loads
+	mux.Unlock()
+	waitFor(t, "next loads", func() bool {
+		mux.Lock()
+		defer mux.Unlock()
+		return loads > n+2
+	})
+	select {
+	case <-changed:
+		t.Error("unexpected notification")
+	default:
+	}
+
+	mux.Lock()
+	name = "B"
+	mux.Unlock()
+	<-changed
+	if b.Name(0, 1) != "B" {
+		t.Errorf("expected B, got %s", b.Name(0, 1))
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	mux.Lock()
+	if errs != 1 {
+		t.Errorf("expected 1 error, got %d", errs)
+	}
+	mux.Unlock()
+}
+
+func TestRefresher_Interval(t *testing.T) {
+
+	for _, interval := range []time.Duration{0, -time.Second} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected panic for interval %s", interval)
+				}
+			}()
+			NewRefresher(NewFlexBook(), func(context.Context, *FlexBook) error { return nil }, interval)
+		}()
+	}
+}
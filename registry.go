@@ -0,0 +1,143 @@
+package refbook
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Registry keeps reference books by name.
+type Registry struct {
+	mux   sync.RWMutex
+	books map[string]*FlexBook
+}
+
+// NewRegistry returns new empty registry.
+func NewRegistry() *Registry {
+	return &Registry{books: make(map[string]*FlexBook)}
+}
+
+// Register adds the book to the registry. If name is empty, table name of
+// the book is used. Returns error if the name is empty or already taken.
+func (r *Registry) Register(name string, b *FlexBook) error {
+	if name == "" {
+		name = b.TableName()
+	}
+	if name == "" {
+		return errors.New("book name is empty")
+	}
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if _, ok := r.books[name]; ok {
+		return fmt.Errorf("book %s already registered", name)
+	}
+	r.books[name] = b
+	return nil
+}
+
+// Book returns the book registered by name.
+// Returns nil if the name is not registered.
+func (r *Registry) Book(name string) *FlexBook {
+	r.mux.RLock()
+	b := r.books[name]
+	r.mux.RUnlock()
+	return b
+}
+
+// lookup returns name of the book registered by name or by table name.
+func (r *Registry) lookup(name string) (string, bool) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	if _, ok := r.books[name]; ok {
+		return name, true
+	}
+	for n, b := range r.books {
+		if b.TableName() == name {
+			return n, true
+		}
+	}
+	return "", false
+}
+
+// Names returns sorted names of registered books.
+func (r *Registry) Names() []string {
+	r.mux.RLock()
+	res := make([]string, 0, len(r.books))
+	for name := range r.books {
+		res = append(res, name)
+	}
+	r.mux.RUnlock()
+
+	sort.Strings(res)
+	return res
+}
+
+// each calls f for all registered books in order of names and joins errors.
+func (r *Registry) each(f func(name string, b *FlexBook) error) error {
+	var errs []error
+	for _, name := range r.Names() {
+		if err := f(name, r.Book(name)); err != nil {
+			errs = append(errs, fmt.Errorf("book %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// LoadFromSQL loads (reloads) all registered books from the database.
+// Table name of the book is used as a source table, name of the book
+// is used if table name is empty.
+func (r *Registry) LoadFromSQL(ctx context.Context, db *sql.DB, f ...func(*SQLOption)) error {
+	return r.each(func(name string, b *FlexBook) error {
+		return loadBookFromSQL(ctx, db, name, b, f)
+	})
+}
+
+// ReloadFromSQL reloads the book registered by name from the database.
+func (r *Registry) ReloadFromSQL(ctx context.Context, db *sql.DB, name string, f ...func(*SQLOption)) error {
+	b := r.Book(name)
+	if b == nil {
+		return fmt.Errorf("book %s not registered", name)
+	}
+	return loadBookFromSQL(ctx, db, name, b, f)
+}
+
+func loadBookFromSQL(ctx context.Context, db *sql.DB, name string, b *FlexBook, f []func(*SQLOption)) error {
+	table := b.TableName()
+	if table == "" {
+		table = name
+	}
+	return b.LoadFromSQL(ctx, db, table, f...)
+}
+
+// Optimize optimizes all registered books.
+func (r *Registry) Optimize() error {
+	return r.each(func(name string, b *FlexBook) error {
+		return b.Optimize()
+	})
+}
+
+// Manifest returns hashes of all registered books taken by HashAll.
+// Hashes are formatted the same way as in the book JSON.
+func (r *Registry) Manifest() map[string]string {
+	res := make(map[string]string)
+	_ = r.each(func(name string, b *FlexBook) error {
+		res[name] = strconv.FormatUint(b.HashAll(), 10)
+		return nil
+	})
+	return res
+}
+
+// ManifestJSON returns hashes of all registered books as JSON
+// object {"party_types": "<hash>", ...}.
+func (r *Registry) ManifestJSON() []byte {
+	buf, _ := json.Marshal(r.Manifest())
+	return buf
+}
@@ -0,0 +1,132 @@
+package refbook
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+
+	db := openFakeDB(t)
+	defer db.Close()
+
+	pt := NewFlexBook(WithThreadSafe(), WithTablename("party_types"))
+	ml := NewFlexBook(WithThreadSafe())
+	et := NewFlexBook(WithThreadSafe(), WithTablename("event_types"))
+
+	r := NewRegistry()
+	if err := r.Register("", pt); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register("ml_party_types", ml); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register("party_types", et); err == nil {
+		t.Error("expected error for duplicated name")
+	}
+	if err := r.Register("", NewFlexBook()); err == nil {
+		t.Error("expected error for empty name")
+	}
+
+	if !reflect.DeepEqual(r.Names(), []string{"ml_party_types", "party_types"}) {
+		t.Errorf("unexpected names %v", r.Names())
+	}
+	if r.Book("party_types") != pt || r.Book("unknown") != nil {
+		t.Error("unexpected Book result")
+	}
+
+	ctx := context.Background()
+	if err := r.LoadFromSQL(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+
+	if name := ml.Name(ToLangCode("ru"), 1); name != "Физ.лицо" {
+		t.Errorf("expected Физ.лицо, got %s", name)
+	}
+	if name := pt.Name(0, 2); name != "Organization" {
+		t.Errorf("expected Organization, got %s", name)
+	}
+
+	m := r.Manifest()
+	if len(m) != 2 || m["party_types"] != strconv.FormatUint(pt.HashAll(), 10) || m["ml_party_types"] == "0" {
+		t.Errorf("unexpected manifest %v", m)
+	}
+
+	var jm map[string]string
+	if err := json.Unmarshal(r.ManifestJSON(), &jm); err != nil || !reflect.DeepEqual(m, jm) {
+		t.Errorf("unexpected manifest JSON %s", r.ManifestJSON())
+	}
+
+	// reload of single book changes its hash only.
+	fakeDB.setTable("party_types_v2", &fakeTable{
+		columns: []string{"id", "name"},
+		rows:    [][]driver.Value{{int64(1), []byte("Person")}},
+	})
+	if err := r.ReloadFromSQL(ctx, db, "unknown"); err == nil {
+		t.Error("expected error for unknown book")
+	}
+	if err := pt.LoadFromSQL(ctx, db, "party_types_v2"); err != nil {
+		t.Fatal(err)
+	}
+	m2 := r.Manifest()
+	if m2["party_types"] == m["party_types"] || m2["ml_party_types"] != m["ml_party_types"] {
+		t.Errorf("unexpected manifest %v after reload", m2)
+	}
+
+	if err := r.ReloadFromSQL(ctx, db, "party_types"); err != nil {
+		t.Fatal(err)
+	}
+	if m3 := r.Manifest(); m3["party_types"] != m["party_types"] {
+		t.Errorf("expected hash %s, got %s", m["party_types"], m3["party_types"])
+	}
+
+	// errors of all books are reported.
+	if err := r.Register("unknown_table", NewFlexBook()); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.LoadFromSQL(ctx, db); err == nil {
+		t.Error("expected error")
+	}
+
+	pt.AddItem(Item{ID: 5, Name: "E"})
+	if r.Manifest()["party_types"] != "0" {
+		t.Error("expected zero hash for changed book")
+	}
+	if err := r.Optimize(); err != nil {
+		t.Fatal(err)
+	}
+	if r.Manifest()["party_types"] == "0" {
+		t.Error("expected hash after Optimize")
+	}
+}
+
+func TestFlexBook_HashAll(t *testing.T) {
+
+	a := NewFlexBook(WithDefaultLang("en"))
+	a.AddMultiLangItem(MultiLangItem{ID: 1, Name: map[string]string{"en": "A", "ru": "AA", "de": "AAA"}})
+	if err := a.Optimize(); err != nil {
+		t.Fatal(err)
+	}
+
+	b := NewFlexBook(WithDefaultLang("en"))
+	b.AddMultiLangItem(MultiLangItem{ID: 1, Name: map[string]string{"de": "AAA", "en": "A", "ru": "AA"}})
+	if err := b.Optimize(); err != nil {
+		t.Fatal(err)
+	}
+
+	if a.HashAll() == 0 || a.HashAll() != b.HashAll() {
+		t.Errorf("expected equal hashes, got %d and %d", a.HashAll(), b.HashAll())
+	}
+
+	b.AddMultiLangItem(MultiLangItem{ID: 1, Name: map[string]string{"de": "AAA", "en": "A", "ru": "AB"}})
+	if err := b.Optimize(); err != nil {
+		t.Fatal(err)
+	}
+	if a.HashAll() == b.HashAll() {
+		t.Error("expected different hashes")
+	}
+}
@@ -0,0 +1,309 @@
+package refbook
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Notification is a message received from a notification channel.
+// Payload keeps a name of the changed book or its table. Empty payload
+// means that any book could be changed, for instance notifications could
+// be lost during reconnect.
+type Notification struct {
+	Channel string
+	Payload string
+}
+
+// Notifier is implemented by a source of database notifications.
+type Notifier interface {
+	// Listen starts listening the channel. It's called again if the channel
+	// returned by Notify is closed.
+	Listen(channel string) error
+
+	// Notify returns channel of notifications. Closed channel means
+	// connection loss.
+	Notify() <-chan Notification
+
+	// Close stops listening, blocked Listen returns.
+	Close() error
+}
+
+// PQNotifier implements Notifier using PostgreSQL LISTEN/NOTIFY.
+// Connection is re-established automatically.
+type PQNotifier struct {
+	l    *pq.Listener
+	ch   chan Notification
+	done chan struct{} // closed by Close.
+	once sync.Once
+}
+
+// NewPQNotifier returns notifier connected to the database by dsn.
+// Reconnect attempts are made with interval growing from minReconnect
+// up to maxReconnect.
+func NewPQNotifier(dsn string, minReconnect, maxReconnect time.Duration) *PQNotifier {
+	n := PQNotifier{
+		l:    pq.NewListener(dsn, minReconnect, maxReconnect, nil),
+		ch:   make(chan Notification, 64),
+		done: make(chan struct{}),
+	}
+	go n.run(n.l.Notify)
+	return &n
+}
+
+// run passes notifications from src to n.ch until src is closed or
+// notifier is closed.
+func (n *PQNotifier) run(src <-chan *pq.Notification) {
+	defer close(n.ch)
+
+	for {
+		var msg Notification
+		select {
+		case <-n.done:
+			return
+		case pn, ok := <-src:
+			if !ok {
+				return
+			}
+			// nil means connection re-established, notifications could
+			// be lost.
+			if pn != nil {
+				msg = Notification{Channel: pn.Channel, Payload: pn.Extra}
+			}
+		}
+
+		select {
+		case <-n.done:
+			return
+		case n.ch <- msg:
+		}
+	}
+}
+
+// Listen implements Notifier interface. It blocks until connection
+// is established.
+func (n *PQNotifier) Listen(channel string) error {
+	if err := n.l.Listen(channel); err != nil && err != pq.ErrChannelAlreadyOpen {
+		return err
+	}
+	return nil
+}
+
+// Notify implements Notifier interface.
+func (n *PQNotifier) Notify() <-chan Notification {
+	return n.ch
+}
+
+// Close implements Notifier interface.
+func (n *PQNotifier) Close() error {
+	n.once.Do(func() { close(n.done) })
+	return n.l.Close()
+}
+
+// Reloader reloads books of the registry from the database when
+// a notification with the book name or table name comes.
+//
+// Notifications can be sent by trigger:
+//
+//	create function refbook_notify() returns trigger as $$
+//	begin
+//	  perform pg_notify('refbook', TG_TABLE_NAME);
+//	  return null;
+//	end $$ language plpgsql;
+//
+//	create trigger party_types_notify after insert or update or delete or truncate
+//	on party_types for each statement execute function refbook_notify();
+type Reloader struct {
+	reg        *Registry
+	n          Notifier
+	channel    string
+	load       func(ctx context.Context, name string) error
+	onError    func(error)
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+// ReloaderOption holds Reloader and Refresher configuration.
+type ReloaderOption struct {
+	onError    func(error)
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	sqlOptions []func(*SQLOption)
+	jitter     time.Duration
+}
+
+// WithErrorHandler sets function called on listening and reloading errors.
+func WithErrorHandler(f func(error)) func(o *ReloaderOption) {
+	return func(o *ReloaderOption) {
+		o.onError = f
+	}
+}
+
+// WithBackoff replaces default intervals (1s, 1m) between attempts to
+// listen after connection loss. The interval is doubled after each
+// failed attempt.
+func WithBackoff(min, max time.Duration) func(o *ReloaderOption) {
+	return func(o *ReloaderOption) {
+		o.minBackoff = min
+		o.maxBackoff = max
+	}
+}
+
+// WithReloadSQLOptions sets options passed to FlexBook.LoadFromSQL.
+func WithReloadSQLOptions(f ...func(*SQLOption)) func(o *ReloaderOption) {
+	return func(o *ReloaderOption) {
+		o.sqlOptions = f
+	}
+}
+
+// NewReloader returns reloader of books of the registry.
+func NewReloader(reg *Registry, db *sql.DB, n Notifier, channel string, f ...func(*ReloaderOption)) *Reloader {
+	o := ReloaderOption{
+		onError:    func(error) {},
+		minBackoff: time.Second,
+		maxBackoff: time.Minute,
+	}
+	for i := range f {
+		f[i](&o)
+	}
+
+	return &Reloader{
+		reg:     reg,
+		n:       n,
+		channel: channel,
+		load: func(ctx context.Context, name string) error {
+			return reg.ReloadFromSQL(ctx, db, name, o.sqlOptions...)
+		},
+		onError:    o.onError,
+		minBackoff: o.minBackoff,
+		maxBackoff: o.maxBackoff,
+	}
+}
+
+var errNotifyClosed = errors.New("notification channel closed")
+
+// Run listens notifications and reloads books until ctx is done.
+// Listening is restarted with backoff after connection loss, all books are
+// reloaded after that. Notifier is closed on exit.
+func (r *Reloader) Run(ctx context.Context) error {
+	// Listen interrupted by ctx returns after the notifier is closed.
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer r.n.Close()
+
+	backoff := r.minBackoff
+	for attempt := 0; ; attempt++ {
+		err := r.listen(ctx, &wg)
+		if err == nil {
+			backoff = r.minBackoff
+			if attempt > 0 {
+				r.reload(ctx, map[string]bool{}, true)
+			}
+			err = r.consume(ctx)
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		r.onError(err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		if backoff *= 2; backoff > r.maxBackoff {
+			backoff = r.maxBackoff
+		}
+	}
+}
+
+// listen calls Notifier.Listen, which can block, until ctx is done.
+// The goroutine calling Listen is tracked by wg, it can outlive listen
+// until the notifier is closed.
+func (r *Reloader) listen(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan error, 1)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		done <- r.n.Listen(r.channel)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-done:
+		return err
+	}
+}
+
+// consume reloads books by notifications until ctx is done or
+// notification channel is closed. Notifications came together are
+// coalesced, so each book is reloaded once.
+func (r *Reloader) consume(ctx context.Context) error {
+	ch := r.n.Notify()
+	for {
+		var (
+			names = map[string]bool{}
+			all   bool
+			err   error
+		)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-ch:
+			if !ok {
+				return errNotifyClosed
+			}
+			all = msg.Payload == ""
+			names[msg.Payload] = true
+		}
+
+	drain:
+		for {
+			select {
+			case msg, ok := <-ch:
+				if !ok {
+					err = errNotifyClosed
+					break drain
+				}
+				all = all || msg.Payload == ""
+				names[msg.Payload] = true
+			default:
+				break drain
+			}
+		}
+
+		r.reload(ctx, names, all)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// reload reloads books by names or table names, unknown names are ignored.
+func (r *Reloader) reload(ctx context.Context, names map[string]bool, all bool) {
+	var list []string
+	if all {
+		list = r.reg.Names()
+	} else {
+		for name := range names {
+			if name, ok := r.reg.lookup(name); ok {
+				list = append(list, name)
+			}
+		}
+	}
+
+	for _, name := range list {
+		if err := r.load(ctx, name); err != nil {
+			r.onError(fmt.Errorf("reload %s: %w", name, err))
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package refbook
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// fakeNotifier is an in-process Notifier.
+type fakeNotifier struct {
+	mux      sync.Mutex
+	ch       chan Notification
+	failures int // Listen fails given number of times.
+	listens  int
+	closed   bool
+}
+
+func (n *fakeNotifier) Listen(channel string) error {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+
+	n.listens++
+	if n.failures > 0 {
+		n.failures--
+		return errors.New("connection refused")
+	}
+	if n.ch == nil {
+		n.ch = make(chan Notification, 16)
+	}
+	return nil
+}
+
+func (n *fakeNotifier) Notify() <-chan Notification {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+	return n.ch
+}
+
+func (n *fakeNotifier) Close() error {
+	n.mux.Lock()
+	n.closed = true
+	n.mux.Unlock()
+	return nil
+}
+
+func (n *fakeNotifier) send(payload string) {
+	n.mux.Lock()
+	ch := n.ch
+	n.mux.Unlock()
+	ch <- Notification{Channel: "refbook", Payload: payload}
+}
+
+// disconnect simulates connection loss.
+func (n *fakeNotifier) disconnect() {
+	n.mux.Lock()
+	close(n.ch)
+	n.ch = nil
+	n.mux.Unlock()
+}
+
+func (n *fakeNotifier) state() (listens int, closed bool) {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+	return n.listens, n.closed
+}
+
+func waitFor(t *testing.T, what string, f func() bool) {
+	t.Helper()
+	deadline := time.NewTimer(2 * time.Second)
+	defer deadline.Stop()
+	tick := time.NewTicker(time.Millisecond)
+	defer tick.Stop()
+
+	for !f() {
+		select {
+		case <-deadline.C:
+			t.Fatalf("timeout waiting for %s", what)
+		case <-tick.C:
+		}
+	}
+}
+
+func setReloadTable(table, name string) {
+	fakeDB.setTable(table, &fakeTable{
+		columns: []string{"id", "name"},
+		rows:    [][]driver.Value{{int64(1), []byte(name)}},
+	})
+}
+
+func TestReloader(t *testing.T) {
+
+	db := openFakeDB(t)
+	defer db.Close()
+
+	setReloadTable("rl_a", "A1")
+	setReloadTable("rl_b", "B1")
+
+	a := NewFlexBook(WithThreadSafe(), WithTablename("rl_a"))
+	b := NewFlexBook(WithThreadSafe(), WithTablename("rl_b"))
+	reg := NewRegistry()
+	if err := reg.Register("a", a); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.Register("b", b); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.LoadFromSQL(context.Background(), db); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		mux  sync.Mutex
+		errs []error
+	)
+	onError := func(err error) {
+		mux.Lock()
+		errs = append(errs, err)
+		mux.Unlock()
+	}
+	errCount := func() int {
+		mux.Lock()
+		defer mux.Unlock()
+		return len(errs)
+	}
+
+	n := &fakeNotifier{failures: 2}
+	r := NewReloader(reg, db, n, "refbook",
+		WithBackoff(time.Millisecond, 4*time.Millisecond), WithErrorHandler(onError))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- r.Run(ctx)
+	}()
+
+	// listen is retried after failures.
+	waitFor(t, "listen", func() bool { return n.Notify() != nil })
+	if errCount() != 2 {
+		t.Errorf("expected 2 errors, got %d", errCount())
+	}
+
+	// notification is consumed after reload of all books following
+	// listen failures.
+	setReloadTable("rl_a", "A2")
+	n.send("rl_a")
+	waitFor(t, "first reload", func() bool { return a.Name(0, 1) == "A2" })
+
+	// readers are not interrupted by reloads.
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if name := a.Name(0, 1); name != "A2" && name != "A3" {
+				t.Errorf("unexpected name %s", name)
+				return
+			}
+		}
+	}()
+
+	// only affected book is reloaded, by table name.
+	bBooks := b.books.Load()
+	setReloadTable("rl_a", "A3")
+	setReloadTable("rl_b", "B2")
+	n.send("rl_a")
+	n.send("rl_a")
+	n.send("unknown")
+	waitFor(t, "reload of a", func() bool { return a.Name(0, 1) == "A3" })
+	close(stop)
+	wg.Wait()
+
+	if b.books.Load() != bBooks || b.Name(0, 1) != "B1" {
+		t.Error("book b must not be reloaded")
+	}
+
+	// by book name.
+	setReloadTable("rl_b", "B3")
+	n.send("b")
+	waitFor(t, "reload of b", func() bool { return b.Name(0, 1) == "B3" })
+
+	// all books are reloaded after reconnect.
+	setReloadTable("rl_a", "A4")
+	setReloadTable("rl_b", "B4")
+	listens, _ := n.state()
+	n.disconnect()
+	waitFor(t, "reconnect", func() bool { l, _ := n.state(); return l > listens })
+	waitFor(t, "reload after reconnect", func() bool { return a.Name(0, 1) == "A4" && b.Name(0, 1) == "B4" })
+
+	// empty payload reloads all books.
+	setReloadTable("rl_a", "A5")
+	setReloadTable("rl_b", "B5")
+	n.send("")
+	waitFor(t, "reload of all", func() bool { return a.Name(0, 1) == "A5" && b.Name(0, 1) == "B5" })
+
+	// reload errors are reported.
+	ec := errCount()
+	fakeDB.setTable("rl_a", &fakeTable{columns: []string{"id"}})
+	n.send("a")
+	waitFor(t, "reload error", func() bool { return errCount() > ec })
+	if a.Name(0, 1) != "A5" {
+		t.Error("failed reload must keep the book")
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if _, closed := n.state(); !closed {
+		t.Error("notifier expected to be closed")
+	}
+}
+
+// blockingNotifier blocks Listen until the notifier is closed and the
+// test releases it.
+type blockingNotifier struct {
+	fakeNotifier
+	listening chan struct{} // closed when Listen is called.
+	closing   chan struct{}
+	release   chan struct{}
+	once      sync.Once
+	returned  atomic.Bool // Listen has returned.
+}
+
+func (n *blockingNotifier) Listen(channel string) error {
+	close(n.listening)
+	<-n.closing
+	<-n.release
+	n.returned.Store(true)
+	return errors.New("listener closed")
+}
+
+func (n *blockingNotifier) Close() error {
+	n.once.Do(func() { close(n.closing) })
+	return nil
+}
+
+func TestReloader_ListenLeak(t *testing.T) {
+
+	n := &blockingNotifier{listening: make(chan struct{}), closing: make(chan struct{}), release: make(chan struct{})}
+	r := NewReloader(NewRegistry(), nil, n, "refbook")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- r.Run(ctx) }()
+
+	<-n.listening
+	cancel()
+
+	// Run closes the notifier and waits for Listen.
+	<-n.closing
+	select {
+	case <-done:
+		t.Fatal("Run returned before Listen")
+	default:
+	}
+	close(n.release)
+
+	if err := <-done; err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if !n.returned.Load() {
+		t.Error("goroutine calling Listen outlived Run")
+	}
+}
+
+func TestPQNotifier_CloseLeak(t *testing.T) {
+
+	n := &PQNotifier{
+		l:    pq.NewListener("host=127.0.0.1 port=1 sslmode=disable", time.Hour, time.Hour, nil),
+		ch:   make(chan Notification),
+		done: make(chan struct{}),
+	}
+
+	src := make(chan *pq.Notification)
+	exited := make(chan struct{})
+	go func() {
+		n.run(src)
+		close(exited)
+	}()
+
+	// nobody reads notifications, run receives one and blocks on sending.
+	src <- &pq.Notification{Channel: "refbook", Extra: "party_types"}
+	n.Close()
+	select {
+	case <-exited:
+	case <-time.After(time.Second):
+		t.Fatal("run did not exit after Close")
+	}
+	if _, ok := <-n.Notify(); ok {
+		t.Error("expected closed notification channel")
+	}
+}
@@ -0,0 +1,293 @@
+package refbook
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Scores of search results by kind of match. Within the kind score grows
+// with the share of the name covered by the query.
+const (
+	scoreExact     = 1.0
+	scorePrefix    = 0.8
+	scoreSubstring = 0.6
+	scoreFuzzy     = 0.4
+	scoreRange     = 0.19
+)
+
+// SearchResultOf describes the item found by Search. If the item is
+// found by alias, Alias keeps it and Start, End refer to Alias.
+type SearchResultOf[K comparable] struct {
+	ID    K
+	Name  string
+	Alias string
+	Score float64 // from 0 to 1, 1 means exact match.
+	Start int     // byte offset of the matched part of Name.
+	End   int     // byte offset next to the matched part of Name.
+}
+
+// SearchResult describes the item of Book found by Search.
+type SearchResult = SearchResultOf[int]
+
+// Search returns up to limit items matching query ordered by relevance:
+// exact match, name starts with query, name contains query, name has
+// a word similar to query considering typos. Matching is insensitive to
+// case and diacritics. Limit <= 0 means no limit. Inactive items are
+// skipped unless option WithInactive is given.
+func (b *BookOf[K, V]) Search(lc LangCode, query string, limit int) []SearchResultOf[K] {
+	sr := newSearcher(query)
+	if sr == nil {
+		return nil
+	}
+
+	var res []SearchResultOf[K]
+	searchItems(sr, b.snapshot().uItems, b.withInactive, func(r SearchResultOf[K]) {
+		res = append(res, r)
+	})
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Score > res[j].Score
+	})
+	if limit > 0 && len(res) > limit {
+		res = res[:limit]
+	}
+	return res
+}
+
+// FlexSearchResult describes the item found by FlexBook.Search.
+// Embedded SearchResult describes the best match, Langs lists all
+// languages matched in order of the book languages.
+type FlexSearchResult struct {
+	SearchResult
+	Lang  LangCode // language of the best match.
+	Langs []LangCode
+}
+
+// Search returns items matching query in any language ordered by
+// relevance, see Book.Search. Each item is returned once. Names missing
+// in a language and taken by fallback are not matched, so Langs lists
+// only languages having the matched name.
+func (b *FlexBook) Search(query string) []FlexSearchResult {
+	sr := newSearcher(query)
+	if sr == nil {
+		return nil
+	}
+
+	var (
+		res []FlexSearchResult
+		idx = map[int]int{} // position in res by id.
+	)
+
+	fb := b.books.Load()
+	for i := range fb.book {
+		lc := fb.bi[i]
+		searchItems(sr, fb.book[i].snapshot().uItems, fb.book[i].withInactive, func(r SearchResult) {
+			j, ok := idx[r.ID]
+			if !ok {
+				idx[r.ID] = len(res)
+				res = append(res, FlexSearchResult{SearchResult: r, Lang: lc, Langs: []LangCode{lc}})
+				return
+			}
+			if r.Score > res[j].Score {
+				res[j].SearchResult, res[j].Lang = r, lc
+			}
+			res[j].Langs = append(res[j].Langs, lc)
+		})
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Score > res[j].Score
+	})
+	return res
+}
+
+// searchItems calls f for items matching the query by name or by any
+// alias, the best match is taken. Inactive items are skipped unless
+// withInactive is true. Names taken from another language are skipped.
+func searchItems[K comparable](sr *searcher, uItems []nameItem[K], withInactive bool, f func(SearchResultOf[K])) {
+	for i := range uItems {
+		ni := &uItems[i]
+		if ni.inactive && !withInactive || ni.fallback {
+			continue
+		}
+
+		var (
+			res   SearchResultOf[K]
+			found bool
+		)
+		for j := -1; j < len(ni.aliases); j++ {
+			x := ni
+			if j >= 0 {
+				x = &ni.aliases[j]
+			}
+			score, fs, fe, ok := sr.match(x.FName)
+			if !ok || found && score <= res.Score {
+				continue
+			}
+			res.Score = score
+			res.Start, res.End = x.span(fs, fe)
+			res.Alias = ""
+			if j >= 0 {
+				res.Alias = x.Name
+			}
+			found = true
+		}
+		if !found {
+			continue
+		}
+		res.ID, res.Name = ni.ID, ni.Name
+		f(res)
+	}
+}
+
+// searcher matches names with the query.
+type searcher struct {
+	q     string // folded query.
+	qr    []rune
+	maxd  int    // max edit distance of fuzzy match.
+	wr    []rune // buffers of fuzzyPrefix.
+	row   []int
+	prev  []int
+	pprev []int
+}
+
+func newSearcher(query string) *searcher {
+	q := fold(strings.TrimSpace(query))
+	if q == "" {
+		return nil
+	}
+
+	sr := searcher{q: q, qr: []rune(q)}
+	switch n := len(sr.qr); {
+	case n < 3:
+		sr.maxd = 0
+	case n < 6:
+		sr.maxd = 1
+	default:
+		sr.maxd = 2
+	}
+	return &sr
+}
+
+// match matches folded name fn with the query. Returns score and bounds
+// of the matched part of fn.
+func (sr *searcher) match(fn string) (score float64, start, end int, ok bool) {
+	ratio := float64(len(sr.q)) / float64(len(fn))
+	switch i := strings.Index(fn, sr.q); {
+	case fn == sr.q:
+		return scoreExact, 0, len(fn), true
+	case i == 0:
+		return scorePrefix + scoreRange*ratio, 0, len(sr.q), true
+	case i > 0:
+		return scoreSubstring + scoreRange*ratio, i, i + len(sr.q), true
+	}
+
+	if sr.maxd == 0 {
+		return 0, 0, 0, false
+	}
+
+	// the word of the name most similar to the query.
+	best := sr.maxd + 1
+	for ws := 0; ws < len(fn); {
+		r, n := utf8.DecodeRuneInString(fn[ws:])
+		if !isWordRune(r) {
+			ws += n
+			continue
+		}
+
+		we := ws + n
+		for we < len(fn) {
+			r, n := utf8.DecodeRuneInString(fn[we:])
+			if !isWordRune(r) {
+				break
+			}
+			we += n
+		}
+
+		if d, n := sr.fuzzyPrefix(fn[ws:we]); d < best {
+			best, start, end = d, ws, ws+n
+		}
+		ws = we
+	}
+
+	if best > sr.maxd {
+		return 0, 0, 0, false
+	}
+	return scoreFuzzy + scoreRange*(1-float64(best)/float64(sr.maxd+1)), start, end, true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// fuzzyPrefix returns the least edit distance between the query and
+// prefixes of word w and byte length of the prefix. Adjacent
+// transposition is a single edit.
+func (sr *searcher) fuzzyPrefix(w string) (int, int) {
+	sr.wr = sr.wr[:0]
+	for _, r := range w {
+		sr.wr = append(sr.wr, r)
+	}
+	qr, wr := sr.qr, sr.wr
+	if len(wr)+sr.maxd < len(qr) {
+		return sr.maxd + 1, 0
+	}
+
+	if cap(sr.row) < len(wr)+1 {
+		sr.row = make([]int, len(wr)+1)
+		sr.prev = make([]int, len(wr)+1)
+		sr.pprev = make([]int, len(wr)+1)
+	}
+	row, prev, pprev := sr.row[:len(wr)+1], sr.prev[:len(wr)+1], sr.pprev[:len(wr)+1]
+
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(qr); i++ {
+		row[0] = i
+		for j := 1; j <= len(wr); j++ {
+			cost := 1
+			if qr[i-1] == wr[j-1] {
+				cost = 0
+			}
+			d := min(prev[j]+1, row[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && qr[i-1] == wr[j-2] && qr[i-2] == wr[j-1] {
+				d = min(d, pprev[j-2]+1)
+			}
+			row[j] = d
+		}
+		pprev, prev, row = prev, row, pprev
+	}
+
+	// prefix closest to the query length wins among equally distant.
+	best, bj := len(qr)+1, 0
+	for j := 1; j <= len(wr); j++ {
+		if d := prev[j]; d < best || d == best && abs(j-len(qr)) < abs(bj-len(qr)) {
+			best, bj = d, j
+		}
+	}
+	n := 0
+	for _, r := range wr[:bj] {
+		n += utf8.RuneLen(r)
+	}
+	return best, n
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// span returns bounds in Name of the part of FName from fs to fe.
+func (ni *nameItem[K]) span(fs, fe int) (int, int) {
+	if ni.fOffs == nil {
+		return fs, fe
+	}
+	start, end := int(ni.fOffs[fs]), int(ni.fOffs[fe-1])
+	_, n := utf8.DecodeRuneInString(ni.Name[end:])
+	return start, end + n
+}
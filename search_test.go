@@ -0,0 +1,124 @@
+package refbook
+
+import (
+	"testing"
+)
+
+func TestBook_Search(t *testing.T) {
+
+	b := NewConcurrentBook()
+	if err := b.Parse([]byte(`[
+		{"id":1,"name":"Organization"},
+		{"id":2,"name":"Non-profit organization"},
+		{"id":3,"name":"Org"},
+		{"id":4,"name":"Individual"},
+		{"id":5,"name":"Société Générale"},
+		{"id":6,"name":"Straße"}
+	]`)); err != nil {
+		t.Fatal(err)
+	}
+
+	tc := []struct {
+		query    string
+		limit    int
+		expected []int
+		match    []string // highlighted parts.
+	}{
+		{"org", 0, []int{3, 1, 2}, []string{"Org", "Org", "org"}},
+		{"ORGANIZATION", 0, []int{1, 2}, []string{"Organization", "organization"}},
+		{"org", 2, []int{3, 1}, []string{"Org", "Org"}},
+		{"organiaztion", 0, []int{1, 2}, []string{"Organization", "organization"}},
+		{"indvidual", 0, []int{4}, []string{"Individual"}},
+		{"generale", 0, []int{5}, []string{"Générale"}},
+		{"strasse", 0, []int{6}, []string{"Straße"}},
+		{"stras", 0, []int{6}, []string{"Straß"}},
+		{"xyz", 0, nil, nil},
+		{" ", 0, nil, nil},
+	}
+
+	for i := range tc {
+		t.Run(tc[i].query, func(t *testing.T) {
+			res := b.Search(0, tc[i].query, tc[i].limit)
+			if len(res) != len(tc[i].expected) {
+				t.Fatalf("expected %v, got %v", tc[i].expected, res)
+			}
+			for j := range res {
+				if res[j].ID != tc[i].expected[j] {
+					t.Errorf("expected %v, got %v", tc[i].expected, res)
+				}
+				if m := res[j].Name[res[j].Start:res[j].End]; m != tc[i].match[j] {
+					t.Errorf("expected highlight %s, got %s", tc[i].match[j], m)
+				}
+				if j > 0 && res[j].Score > res[j-1].Score {
+					t.Error("results must be ordered by score")
+				}
+			}
+		})
+	}
+
+	if res := b.Search(0, "Org", 1); res[0].Score != 1 {
+		t.Errorf("expected score 1 of exact match, got %v", res[0].Score)
+	}
+
+	// index follows changes.
+	b.Set(7, "Orgue")
+	b.Delete(3)
+	if res := b.Search(0, "org", 0); len(res) != 3 || res[0].ID != 7 {
+		t.Errorf("unexpected result after change %v", res)
+	}
+}
+
+func TestFlexBook_Search(t *testing.T) {
+
+	b := NewFlexBook(WithThreadSafe(), WithDefaultLang("en"))
+	b.AddMultiLangItems([]MultiLangItem{
+		{ID: 1, Name: map[string]string{"en": "Organization", "ru": "Организация", "de": "Organisation"}},
+		{ID: 2, Name: map[string]string{"en": "Individual", "ru": "Физлицо", "de": "Einzelperson"}},
+		{ID: 3, Name: map[string]string{"en": "Other", "ru": "Другое", "de": "Andere"}},
+	})
+
+	en, ru, de := ToLangCode("en"), ToLangCode("ru"), ToLangCode("de")
+
+	res := b.Search("organisation")
+	if len(res) != 1 || res[0].ID != 1 {
+		t.Fatalf("expected item 1, got %v", res)
+	}
+	// exact match in de is better than similar word in en.
+	if res[0].Lang != de || res[0].Score != 1 || len(res[0].Langs) != 2 || res[0].Langs[0] != en || res[0].Langs[1] != de {
+		t.Errorf("unexpected result %+v", res[0])
+	}
+
+	res = b.Search("физ")
+	if len(res) != 1 || res[0].ID != 2 || res[0].Lang != ru || res[0].Name[res[0].Start:res[0].End] != "Физ" {
+		t.Errorf("unexpected result %+v", res)
+	}
+
+	// Other, Andere, Einzelperson.
+	res = b.Search("er")
+	if len(res) != 2 || res[0].ID != 3 || res[1].ID != 2 {
+		t.Errorf("unexpected result %+v", res)
+	}
+
+	if res := b.Search("xyz"); len(res) != 0 {
+		t.Errorf("expected nothing, got %v", res)
+	}
+}
+
+func TestFlexBook_SearchSkipsFallbackNames(t *testing.T) {
+
+	b := NewFlexBook(WithDefaultLang("en"))
+	b.AddMultiLangItems([]MultiLangItem{
+		{ID: 1, Name: map[string]string{"en": "Hello", "ru": "Привет"}},
+		{ID: 2, Name: map[string]string{"en": "World"}},
+		{ID: 3, Name: map[string]string{"ru": "Мир"}},
+	})
+
+	res := b.Search("World")
+	if len(res) != 1 || res[0].ID != 2 || len(res[0].Langs) != 1 || res[0].Langs[0] != ToLangCode("en") {
+		t.Errorf("expected item 2 in en only, got %+v", res)
+	}
+
+	if res := b.Search(NotFoundName); len(res) != 0 {
+		t.Errorf("expected nothing, got %+v", res)
+	}
+}
@@ -0,0 +1,268 @@
+package refbook
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// SQLOption holds LoadFromSQL configuration.
+type SQLOption struct {
+	idColumn        string
+	nameColumn      string
+	sortOrderColumn string
+	activeColumn    string
+	validFromColumn string
+	validToColumn   string
+	aliasesColumn   string
+}
+
+// WithIDColumn replaces default column name "id".
+func WithIDColumn(column string) func(o *SQLOption) {
+	return func(o *SQLOption) {
+		o.idColumn = column
+	}
+}
+
+// WithNameColumn replaces default column name "name".
+func WithNameColumn(column string) func(o *SQLOption) {
+	return func(o *SQLOption) {
+		o.nameColumn = column
+	}
+}
+
+// WithSortOrderColumn informs that sort order of items is taken from
+// the integer column, see WithOrder. NULL means 0.
+func WithSortOrderColumn(column string) func(o *SQLOption) {
+	return func(o *SQLOption) {
+		o.sortOrderColumn = column
+	}
+}
+
+// WithActiveColumn informs that items are active if the boolean column
+// is true or NULL, see ItemOf.
+func WithActiveColumn(column string) func(o *SQLOption) {
+	return func(o *SQLOption) {
+		o.activeColumn = column
+	}
+}
+
+// WithValidityColumns informs that validity interval of items is taken
+// from timestamp columns, NULL means unbounded. Rows having the same id
+// are versions of the item, see ItemOf.
+func WithValidityColumns(from, to string) func(o *SQLOption) {
+	return func(o *SQLOption) {
+		o.validFromColumn = from
+		o.validToColumn = to
+	}
+}
+
+// WithAliasesColumn informs that aliases of items are taken from the column
+// keeping JSON array ["USA","America"], or JSON object with language codes
+// as keys {"en":["USA"]} if column name keeps JSON object. NULL means no
+// aliases.
+func WithAliasesColumn(column string) func(o *SQLOption) {
+	return func(o *SQLOption) {
+		o.aliasesColumn = column
+	}
+}
+
+func newSQLOption(f []func(*SQLOption)) SQLOption {
+	o := SQLOption{idColumn: "id", nameColumn: "name"}
+	for i := range f {
+		f[i](&o)
+	}
+	return o
+}
+
+// queryItems reads id, name pairs from the table. Returns items if
+// name column is a text and mlItems if name column is a JSON object
+// with language codes as keys.
+//
+// Column type is taken from the driver (JSON, JSONB). If the driver does
+// not report it, the type is recognized by the values the same way
+// as FlexBook.Parse does it.
+func queryItems(ctx context.Context, db *sql.DB, table string, o SQLOption) (items []Item, mlItems []MultiLangItem, err error) {
+
+	if table == "" {
+		return nil, nil, errors.New("table name is empty")
+	}
+
+	// table and column names are not escaped, it allows schema qualified names.
+	cols := []string{o.idColumn, o.nameColumn}
+	for _, c := range []string{o.sortOrderColumn, o.activeColumn, o.validFromColumn, o.validToColumn, o.aliasesColumn} {
+		if c != "" {
+			cols = append(cols, c)
+		}
+	}
+	qry := "select " + strings.Join(cols, ", ") + " from " + table
+
+	rows, err := db.QueryContext(ctx, qry)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	isJSON := false
+	if cts, err := rows.ColumnTypes(); err == nil && len(cts) >= 2 {
+		switch strings.ToUpper(cts[1].DatabaseTypeName()) {
+		case "JSON", "JSONB":
+			isJSON = true
+		}
+	}
+
+	type row struct {
+		id        int
+		name      []byte
+		sortOrder sql.NullInt64
+		isActive  sql.NullBool
+		validFrom sql.NullTime
+		validTo   sql.NullTime
+		aliases   []byte
+	}
+
+	var (
+		rs     []row
+		ml, sl int
+	)
+
+	for rows.Next() {
+		var r row
+		dst := []interface{}{&r.id, &r.name}
+		if o.sortOrderColumn != "" {
+			dst = append(dst, &r.sortOrder)
+		}
+		if o.activeColumn != "" {
+			dst = append(dst, &r.isActive)
+		}
+		if o.validFromColumn != "" {
+			dst = append(dst, &r.validFrom)
+		}
+		if o.validToColumn != "" {
+			dst = append(dst, &r.validTo)
+		}
+		if o.aliasesColumn != "" {
+			dst = append(dst, &r.aliases)
+		}
+		if err := rows.Scan(dst...); err != nil {
+			return nil, nil, err
+		}
+		rs = append(rs, r)
+
+		switch {
+		case r.name == nil:
+		case isJSON || gjson.ParseBytes(r.name).IsObject():
+			ml++
+		default:
+			sl++
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if ml > 0 && sl > 0 {
+		return nil, nil, errors.New("name column has different types")
+	}
+
+	if ml > 0 {
+		mlItems = make([]MultiLangItem, 0, len(rs))
+		for i := range rs {
+			item := MultiLangItem{
+				ID:        rs[i].id,
+				Name:      map[string]string{},
+				SortOrder: int(rs[i].sortOrder.Int64),
+				Inactive:  rs[i].isActive.Valid && !rs[i].isActive.Bool,
+				ValidFrom: rs[i].validFrom.Time,
+				ValidTo:   rs[i].validTo.Time,
+			}
+			if rs[i].name != nil {
+				if err := json.Unmarshal(rs[i].name, &item.Name); err != nil {
+					return nil, nil, err
+				}
+			}
+			if rs[i].aliases != nil {
+				if err := json.Unmarshal(rs[i].aliases, &item.Aliases); err != nil {
+					return nil, nil, err
+				}
+			}
+			mlItems = append(mlItems, item)
+		}
+		return nil, mlItems, nil
+	}
+
+	items = make([]Item, 0, len(rs))
+	for i := range rs {
+		item := Item{
+			ID:        rs[i].id,
+			Name:      string(rs[i].name),
+			SortOrder: int(rs[i].sortOrder.Int64),
+			Inactive:  rs[i].isActive.Valid && !rs[i].isActive.Bool,
+			ValidFrom: rs[i].validFrom.Time,
+			ValidTo:   rs[i].validTo.Time,
+		}
+		if rs[i].aliases != nil {
+			if err := json.Unmarshal(rs[i].aliases, &item.Aliases); err != nil {
+				return nil, nil, err
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil, nil
+}
+
+// LoadFromSQL replaces items of the book by rows of the table.
+// Table has to have columns "id" and "name", names can be changed by
+// options WithIDColumn and WithNameColumn.
+// If column name keeps JSON object, the name in the language of the book
+// is taken, see WithDefaultLang.
+func (b *Book) LoadFromSQL(ctx context.Context, db *sql.DB, table string, f ...func(*SQLOption)) error {
+
+	items, mlItems, err := queryItems(ctx, db, table, newSQLOption(f))
+	if err != nil {
+		return err
+	}
+
+	if mlItems != nil {
+		lc := b.langCode()
+
+		items = make([]Item, 0, len(mlItems))
+		for i := range mlItems {
+			var name string
+			for lang, n := range mlItems[i].Name {
+				if ToLangCode(lang) == lc {
+					name = n
+					break
+				}
+			}
+			items = append(items, mlItems[i].item(name, mlItems[i].aliases(lc)))
+		}
+	}
+
+	return b.load(items)
+}
+
+// LoadFromSQL replaces items of the book by rows of the table.
+// If table is empty, the name given by WithTablename is used.
+// Column name can be TEXT or JSONB, see Book.LoadFromSQL.
+func (b *FlexBook) LoadFromSQL(ctx context.Context, db *sql.DB, table string, f ...func(*SQLOption)) error {
+
+	if table == "" {
+		table = b.tableName
+	}
+
+	items, mlItems, err := queryItems(ctx, db, table, newSQLOption(f))
+	if err != nil {
+		return err
+	}
+
+	if mlItems != nil {
+		return b.Replace(mlItems)
+	}
+	return b.ReplaceItems(items)
+}
@@ -0,0 +1,415 @@
+package refbook
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeTable is a table served by fakeDriver.
+type fakeTable struct {
+	columns []string
+	types   []string // database type names, can be empty.
+	rows    [][]driver.Value
+}
+
+// fakeDriver is a local stand-in for a database driver. It understands
+// queries "select <col>, <col>[, <col>...] from <table>" only.
+type fakeDriver struct {
+	mux    sync.Mutex
+	tables map[string]*fakeTable
+}
+
+var fakeDB = &fakeDriver{tables: map[string]*fakeTable{}}
+
+func (d *fakeDriver) setTable(name string, t *fakeTable) {
+	d.mux.Lock()
+	d.tables[name] = t
+	d.mux.Unlock()
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	return &fakeConn{d: d}, nil
+}
+
+type fakeConn struct {
+	d *fakeDriver
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepare is not supported")
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	f := strings.Fields(strings.Replace(query, ",", " ", -1))
+	n := len(f) - 2 // position of "from".
+	if n < 3 || f[0] != "select" || f[n] != "from" {
+		return nil, errors.New("unsupported query: " + query)
+	}
+
+	c.d.mux.Lock()
+	t, ok := c.d.tables[f[n+1]]
+	c.d.mux.Unlock()
+	if !ok {
+		return nil, errors.New(`relation "` + f[n+1] + `" does not exist`)
+	}
+
+	r := fakeRows{t: t}
+	for _, col := range f[1:n] {
+		idx := -1
+		for i := range t.columns {
+			if t.columns[i] == col {
+				idx = i
+			}
+		}
+		if idx == -1 {
+			return nil, errors.New(`column "` + col + `" does not exist`)
+		}
+		r.cols = append(r.cols, idx)
+	}
+	return &r, nil
+}
+
+type fakeRows struct {
+	t    *fakeTable
+	cols []int
+	pos  int
+}
+
+func (r *fakeRows) Columns() []string {
+	res := make([]string, len(r.cols))
+	for i, c := range r.cols {
+		res[i] = r.t.columns[c]
+	}
+	return res
+}
+
+func (r *fakeRows) ColumnTypeDatabaseTypeName(index int) string {
+	if len(r.t.types) == 0 {
+		return ""
+	}
+	return r.t.types[r.cols[index]]
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.t.rows) {
+		return io.EOF
+	}
+	for i, c := range r.cols {
+		dest[i] = r.t.rows[r.pos][c]
+	}
+	r.pos++
+	return nil
+}
+
+func openFakeDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("refbookfake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func init() {
+	sql.Register("refbookfake", fakeDB)
+
+	fakeDB.setTable("party_types", &fakeTable{
+		columns: []string{"id", "name"},
+		types:   []string{"INT4", "TEXT"},
+		rows: [][]driver.Value{
+			{int64(1), []byte("Individual")},
+			{int64(2), []byte("Organization")},
+			{int64(3), nil},
+		},
+	})
+	fakeDB.setTable("ml_party_types", &fakeTable{
+		columns: []string{"id", "name"},
+		types:   []string{"INT4", "JSONB"},
+		rows: [][]driver.Value{
+			{int64(1), []byte(`{"en":"Individual","ru":"Физ.лицо"}`)},
+			{int64(2), []byte(`{"en":"Organization","ru":"Организация"}`)},
+			{int64(3), []byte(`{"ru":"ИП"}`)},
+		},
+	})
+	fakeDB.setTable("event_types", &fakeTable{
+		columns: []string{"event_type_id", "code", "title"},
+		rows: [][]driver.Value{
+			{int64(1), "CONLOST", []byte(`{"en":"Connection lost","ru":"Связь потеряна"}`)},
+			{int64(2), "SERVERUP", []byte(`{"en":"Server up","ru":"Сервер поднят"}`)},
+		},
+	})
+	fakeDB.setTable("sorted_types", &fakeTable{
+		columns: []string{"id", "name", "pos"},
+		rows: [][]driver.Value{
+			{int64(1), []byte(`{"en":"First","ru":"Первый"}`), int64(2)},
+			{int64(2), []byte(`{"en":"Second","ru":"Второй"}`), int64(1)},
+			{int64(3), []byte(`{"en":"Third","ru":"Третий"}`), nil},
+		},
+	})
+	fakeDB.setTable("payment_types", &fakeTable{
+		columns: []string{"id", "name", "is_active"},
+		rows: [][]driver.Value{
+			{int64(1), []byte("Cash"), true},
+			{int64(2), []byte("Cheque"), false},
+			{int64(3), []byte("Card"), nil},
+		},
+	})
+	fakeDB.setTable("vat_rates", &fakeTable{
+		columns: []string{"id", "name", "valid_from", "valid_to"},
+		rows: [][]driver.Value{
+			{int64(1), []byte("18%"), nil, time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)},
+			{int64(1), []byte("20%"), time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), nil},
+			{int64(2), []byte("0%"), nil, nil},
+		},
+	})
+	fakeDB.setTable("vat_rates_overlapped", &fakeTable{
+		columns: []string{"id", "name", "valid_from", "valid_to"},
+		rows: [][]driver.Value{
+			{int64(1), []byte("18%"), nil, time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)},
+			{int64(1), []byte("20%"), time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), nil},
+			{int64(2), []byte("0%"), nil, nil},
+		},
+	})
+	fakeDB.setTable("countries", &fakeTable{
+		columns: []string{"id", "name", "aliases"},
+		rows: [][]driver.Value{
+			{int64(1), []byte(`{"en":"United States","ru":"США"}`), []byte(`{"en":["USA"],"ru":["Америка"]}`)},
+			{int64(2), []byte(`{"en":"Germany","ru":"Германия"}`), nil},
+		},
+	})
+	fakeDB.setTable("mixed", &fakeTable{
+		columns: []string{"id", "name"},
+		rows: [][]driver.Value{
+			{int64(1), []byte(`{"en":"Hello"}`)},
+			{int64(2), []byte(`World`)},
+		},
+	})
+}
+
+func TestBook_LoadFromSQL(t *testing.T) {
+
+	db := openFakeDB(t)
+	defer db.Close()
+
+	b := NewBook()
+	if err := b.LoadFromSQL(context.Background(), db, "party_types"); err != nil {
+		t.Fatal(err)
+	}
+
+	tc := []Item{{ID: 1, Name: "Individual"}, {ID: 2, Name: "Organization"}, {ID: 3, Name: ""}}
+	if b.Len() != len(tc) {
+		t.Errorf("expected %d items, got %d", len(tc), b.Len())
+	}
+	for i := range tc {
+		if name := b.Name(0, tc[i].ID); name != tc[i].Name {
+			t.Errorf("expected %s, got %s", tc[i].Name, name)
+		}
+	}
+
+	if b.Hash() == 0 || len(b.JSON()) == 0 {
+		t.Error("expected optimized book")
+	}
+
+	if err := b.LoadFromSQL(context.Background(), db, "ml_party_types"); err != nil {
+		t.Fatal(err)
+	}
+	if name := b.Name(0, 2); name != "Organization" {
+		t.Errorf("expected Organization, got %s", name)
+	}
+	if name := b.Name(0, 3); name != "" {
+		t.Errorf("expected empty name, got %s", name)
+	}
+
+	// the name in the language of the book is taken.
+	b = NewBook(WithDefaultLang("ru"))
+	if err := b.LoadFromSQL(context.Background(), db, "ml_party_types"); err != nil {
+		t.Fatal(err)
+	}
+	if name := b.Name(0, 3); name != "ИП" {
+		t.Errorf("expected ИП, got %s", name)
+	}
+}
+
+func TestBook_LoadFromSQLErrors(t *testing.T) {
+
+	db := openFakeDB(t)
+	defer db.Close()
+	ctx := context.Background()
+
+	tc := []struct {
+		name  string
+		table string
+		f     []func(*SQLOption)
+	}{
+		{"empty", "", nil},
+		{"unknown table", "unknown", nil},
+		{"unknown column", "party_types", []func(*SQLOption){WithNameColumn("title")}},
+		{"mixed", "mixed", nil},
+	}
+
+	for i := range tc {
+		t.Run(tc[i].name, func(t *testing.T) {
+			if err := NewBook().LoadFromSQL(ctx, db, tc[i].table, tc[i].f...); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestFlexBook_LoadFromSQL(t *testing.T) {
+
+	db := openFakeDB(t)
+	defer db.Close()
+
+	b := NewFlexBook(WithThreadSafe(), WithTablename("ml_party_types"))
+	if err := b.LoadFromSQL(context.Background(), db, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	tc := []struct {
+		id       int
+		expected map[string]string
+	}{
+		{1, map[string]string{"en": "Individual", "ru": "Физ.лицо"}},
+		{2, map[string]string{"en": "Organization", "ru": "Организация"}},
+		{3, map[string]string{"en": NotFoundName, "ru": "ИП"}},
+	}
+
+	if b.Len() != len(tc) {
+		t.Errorf("expected %d items, got %d", len(tc), b.Len())
+	}
+
+	for i := range tc {
+		for lang, expected := range tc[i].expected {
+			if name := b.Name(ToLangCode(lang), tc[i].id); name != expected {
+				t.Errorf("lang: %s, expected %s, got %s", lang, expected, name)
+			}
+		}
+	}
+
+	if b.Hash("ru") == 0 {
+		t.Error("expected optimized book")
+	}
+
+	// reload replaces content.
+	if err := b.LoadFromSQL(context.Background(), db, "party_types"); err != nil {
+		t.Fatal(err)
+	}
+	if name := b.Name(ToLangCode("ru"), 1); name != "Individual" {
+		t.Errorf("expected Individual, got %s", name)
+	}
+}
+
+func TestFlexBook_LoadFromSQLColumns(t *testing.T) {
+
+	db := openFakeDB(t)
+	defer db.Close()
+
+	b := NewFlexBook()
+	err := b.LoadFromSQL(context.Background(), db, "event_types",
+		WithIDColumn("event_type_id"), WithNameColumn("title"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if name := b.Name(ToLangCode("ru"), 2); name != "Сервер поднят" {
+		t.Errorf("expected Сервер поднят, got %s", name)
+	}
+
+	if err := NewFlexBook().LoadFromSQL(context.Background(), db, ""); err == nil {
+		t.Error("expected error if table name is empty")
+	}
+}
+
+func TestFlexBook_LoadFromSQLSortOrder(t *testing.T) {
+
+	db := openFakeDB(t)
+	defer db.Close()
+
+	b := NewFlexBook(WithDefaultLang("en"), WithOrder(OrderSortOrder))
+	err := b.LoadFromSQL(context.Background(), db, "sorted_types", WithSortOrderColumn("pos"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, lang := range []string{"en", "ru"} {
+		if res, expected := itemIDs(b.Items(lang)), []int{3, 2, 1}; !reflect.DeepEqual(res, expected) {
+			t.Errorf("%s: expected %v, got %v", lang, expected, res)
+		}
+	}
+
+	bk := NewBook(WithOrder(OrderSortOrder))
+	if err := bk.LoadFromSQL(context.Background(), db, "sorted_types", WithSortOrderColumn("pos")); err != nil {
+		t.Fatal(err)
+	}
+	if res, expected := itemIDs(bk.Items()), []int{3, 2, 1}; !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected %v, got %v", expected, res)
+	}
+}
+
+func TestBook_LoadFromSQLActive(t *testing.T) {
+
+	db := openFakeDB(t)
+	defer db.Close()
+
+	b := NewBook()
+	if err := b.LoadFromSQL(context.Background(), db, "payment_types", WithActiveColumn("is_active")); err != nil {
+		t.Fatal(err)
+	}
+
+	if res, expected := itemIDs(b.ActiveItems()), []int{1, 3}; !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected %v, got %v", expected, res)
+	}
+	if b.Name(0, 2) != "Cheque" {
+		t.Errorf("expected Cheque, got %s", b.Name(0, 2))
+	}
+}
+
+func TestBook_LoadFromSQLValidity(t *testing.T) {
+
+	db := openFakeDB(t)
+	defer db.Close()
+
+	b := NewBook()
+	if err := b.LoadFromSQL(context.Background(), db, "vat_rates", WithValidityColumns("valid_from", "valid_to")); err != nil {
+		t.Fatal(err)
+	}
+
+	if name := b.Name(0, 1); name != "20%" {
+		t.Errorf("expected 20%%, got %s", name)
+	}
+	if name := b.NameAt(0, 1, time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)); name != "18%" {
+		t.Errorf("expected 18%%, got %s", name)
+	}
+	if name := b.NameAt(0, 2, time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)); name != "0%" {
+		t.Errorf("expected 0%%, got %s", name)
+	}
+
+	err := b.LoadFromSQL(context.Background(), db, "vat_rates_overlapped", WithValidityColumns("valid_from", "valid_to"))
+	if err == nil || err.Error() != "item 1: period of id 1 overlaps another period" {
+		t.Errorf("unexpected error %v", err)
+	}
+	err = NewFlexBook().LoadFromSQL(context.Background(), db, "vat_rates_overlapped", WithValidityColumns("valid_from", "valid_to"))
+	if err == nil || err.Error() != "item 1: period of id 1 overlaps another period" {
+		t.Errorf("unexpected error %v", err)
+	}
+	if name := b.Name(0, 1); name != "20%" {
+		t.Errorf("expected book is not changed, got %s", name)
+	}
+}
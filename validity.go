@@ -0,0 +1,251 @@
+package refbook
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// isBounded returns true if the item has validity interval.
+func (item *ItemOf[K, V]) isBounded() bool {
+	return !item.ValidFrom.IsZero() || !item.ValidTo.IsZero()
+}
+
+// isValidAt returns true if t is within validity interval of the item.
+func (item *ItemOf[K, V]) isValidAt(t time.Time) bool {
+	return (item.ValidFrom.IsZero() || !t.Before(item.ValidFrom)) &&
+		(item.ValidTo.IsZero() || t.Before(item.ValidTo))
+}
+
+// setVersion adds the version of the item or replaces the version valid
+// from the same time. Item without validity interval replaces all
+// versions. Returns version of the item valid now and false if versions
+// are not changed.
+func (s *bookSnapshot[K, V]) setVersion(vs []ItemOf[K, V], item ItemOf[K, V]) (ItemOf[K, V], bool) {
+	if !item.isBounded() {
+		delete(s.periods, item.ID)
+		return item, true
+	}
+
+	i := sort.Search(len(vs), func(i int) bool {
+		return !vs[i].ValidFrom.Before(item.ValidFrom)
+	})
+	isReplaced := i < len(vs) && vs[i].ValidFrom.Equal(item.ValidFrom)
+	if isReplaced && equalItems(vs[i], item) {
+		return currentVersion(vs), false
+	}
+
+	// versions are shared with other snapshots, they are copied.
+	nvs := append(make([]ItemOf[K, V], 0, len(vs)+1), vs[:i]...)
+	nvs = append(nvs, item)
+	if isReplaced {
+		i++
+	}
+	nvs = append(nvs, vs[i:]...)
+
+	if s.periods == nil {
+		s.periods = make(map[K][]ItemOf[K, V])
+	}
+	s.periods[item.ID] = nvs
+	return currentVersion(nvs), true
+}
+
+// checkPeriods returns error if validity interval of an item is invalid
+// or periods of versions of an id overlap, including versions kept by s.
+// Versions are merged like setVersion does it. Nil s means empty book.
+func checkPeriods[K comparable, V any](s *bookSnapshot[K, V], items []ItemOf[K, V]) error {
+
+	// version keeps index of the item, -1 for versions of s.
+	type version struct {
+		from, to time.Time
+		idx      int
+	}
+
+	periods := make(map[K][]version)
+	for i := range items {
+		if err := items[i].validate(); err != nil {
+			return fmt.Errorf("item %d: %w", i, err)
+		}
+
+		id := items[i].ID
+		vs, ok := periods[id]
+		if !ok && s != nil {
+			for _, v := range s.periods[id] {
+				vs = append(vs, version{v.ValidFrom, v.ValidTo, -1})
+			}
+		}
+		if !items[i].isBounded() {
+			periods[id] = vs[:0]
+			continue
+		}
+
+		j := sort.Search(len(vs), func(j int) bool {
+			return !vs[j].from.Before(items[i].ValidFrom)
+		})
+		v := version{items[i].ValidFrom, items[i].ValidTo, i}
+		if j < len(vs) && vs[j].from.Equal(v.from) {
+			vs[j] = v
+		} else {
+			vs = append(vs[:j], append([]version{v}, vs[j:]...)...)
+		}
+		periods[id] = vs
+	}
+
+	// versions are sorted by start, it's enough to compare neighbours.
+	for i := range items {
+		vs := periods[items[i].ID]
+		for j := 1; j < len(vs); j++ {
+			if vs[j-1].to.IsZero() || vs[j-1].to.After(vs[j].from) {
+				return fmt.Errorf("item %d: period of id %v overlaps another period",
+					max(vs[j-1].idx, vs[j].idx), items[i].ID)
+			}
+		}
+	}
+	return nil
+}
+
+// currentVersion returns the latest version started before now. It's
+// the version valid now if any. Returns the first version if all of them
+// start in future.
+func currentVersion[K comparable, V any](vs []ItemOf[K, V]) ItemOf[K, V] {
+	now := time.Now()
+	for i := len(vs) - 1; i >= 0; i-- {
+		if !vs[i].ValidFrom.After(now) {
+			return vs[i]
+		}
+	}
+	return vs[0]
+}
+
+// versionAt returns index of the version valid at t, -1 if not found.
+// The latest started version wins if intervals overlap.
+func versionAt[K comparable, V any](vs []ItemOf[K, V], t time.Time) int {
+	for i := len(vs) - 1; i >= 0; i-- {
+		if vs[i].isValidAt(t) {
+			return i
+		}
+	}
+	return -1
+}
+
+// versions returns items where items having validity intervals are
+// presented by all their versions.
+func (s *bookSnapshot[K, V]) versions() []ItemOf[K, V] {
+	if len(s.periods) == 0 {
+		return s.jsonInput.Items
+	}
+
+	res := make([]ItemOf[K, V], 0, len(s.jsonInput.Items))
+	for _, item := range s.jsonInput.Items {
+		if vs, ok := s.periods[item.ID]; ok {
+			res = append(res, vs...)
+			continue
+		}
+		res = append(res, item)
+	}
+	return res
+}
+
+// versionsOf returns versions of the item by id, the item itself if it
+// has no validity interval.
+func (s *bookSnapshot[K, V]) versionsOf(id K) []ItemOf[K, V] {
+	if vs, ok := s.periods[id]; ok {
+		return vs
+	}
+	if i := s.position(id); i != -1 {
+		return s.jsonInput.Items[i : i+1]
+	}
+	return nil
+}
+
+// valueAt returns payload of the item valid at t.
+func (s *bookSnapshot[K, V]) valueAt(id K, t time.Time) (V, bool) {
+	if vs, ok := s.periods[id]; ok {
+		if i := versionAt(vs, t); i != -1 {
+			return vs[i].Name, true
+		}
+		var zero V
+		return zero, false
+	}
+	v, ok := s.m[id]
+	return v, ok
+}
+
+// itemsAt returns items valid at t. Items having validity intervals are
+// presented by versions valid at t.
+func (s *bookSnapshot[K, V]) itemsAt(t time.Time) []ItemOf[K, V] {
+	if len(s.periods) == 0 {
+		return s.jsonInput.Items
+	}
+
+	res := make([]ItemOf[K, V], 0, len(s.jsonInput.Items))
+	for _, item := range s.jsonInput.Items {
+		if vs, ok := s.periods[item.ID]; ok {
+			i := versionAt(vs, t)
+			if i == -1 {
+				continue
+			}
+			item = vs[i]
+		}
+		res = append(res, item)
+	}
+	return res
+}
+
+// NameAt returns name of the item valid at t. Returns variable
+// NotFoundName if id is not found or the item is not valid at t.
+//
+// Name, IsExist and JSON use version of the item valid at the moment of
+// loading. If there is no one, the last version started before.
+func (b *BookOf[K, V]) NameAt(lc LangCode, id K, t time.Time) string {
+	if b == nil {
+		return NotFoundName
+	}
+
+	if v, ok := b.snapshot().valueAt(id, t); ok {
+		return b.nameOf(v)
+	}
+	return NotFoundName
+}
+
+// IsExistAt returns true if item with id is valid at t.
+func (b *BookOf[K, V]) IsExistAt(id K, t time.Time) bool {
+	_, ok := b.snapshot().valueAt(id, t)
+	return ok
+}
+
+// JSONAt returns JSON of the book as of t. Items not valid at t are
+// skipped, items having validity intervals are presented by versions
+// valid at t. Unlike JSON, the result is built on every call.
+func (b *BookOf[K, V]) JSONAt(t time.Time) ([]byte, error) {
+	items := b.snapshot().itemsAt(t)
+	h, err := hashItems(items, nil)
+	if err != nil {
+		return nil, err
+	}
+	return b.marshal(items, h)
+}
+
+// NameAt returns name of the item in language lc valid at t,
+// see Book.NameAt.
+func (b *FlexBook) NameAt(lc LangCode, id int, t time.Time) string {
+	fb := b.books.Load()
+	return fb.book[fb.langIndex(lc, &b.langs)].NameAt(lc, id, t)
+}
+
+// IsExistAt returns true if item with id is valid at t.
+func (b *FlexBook) IsExistAt(id int, t time.Time) bool {
+	return b.books.Load().book[0].IsExistAt(id, t)
+}
+
+// BookAsJSONAt adds to dst JSON of the book in language lang as of t,
+// see Book.JSONAt.
+func (b *FlexBook) BookAsJSONAt(lang string, t time.Time, dst *[]byte) error {
+	fb := b.books.Load()
+	buf, err := fb.book[fb.languageIndex(lang, &b.langs)].JSONAt(t)
+	if err != nil {
+		return err
+	}
+	*dst = append(*dst, buf...)
+	return nil
+}
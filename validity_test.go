@@ -0,0 +1,305 @@
+package refbook
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestBook_Validity(t *testing.T) {
+
+	src := []byte(`[
+		{"id":1,"name":"18%","valid_to":"2019-01-01"},
+		{"id":1,"name":"20%","valid_from":"2019-01-01"},
+		{"id":2,"name":"10%"},
+		{"id":3,"name":"5%","valid_from":"2010-01-01","valid_to":"2015-01-01"}
+	]`)
+
+	b := NewConcurrentBook()
+	if err := b.Parse(src); err != nil {
+		t.Fatal(err)
+	}
+
+	if name := b.Name(0, 1); name != "20%" {
+		t.Errorf("expected 20%%, got %s", name)
+	}
+
+	tcs := []struct {
+		id       int
+		at       time.Time
+		expected string
+	}{
+		{1, date(2018, 6, 1), "18%"},
+		{1, date(2019, 1, 1), "20%"},
+		{2, date(2000, 1, 1), "10%"},
+		{3, date(2012, 1, 1), "5%"},
+		{3, date(2016, 1, 1), NotFoundName},
+		{4, date(2016, 1, 1), NotFoundName},
+	}
+	for _, tc := range tcs {
+		if name := b.NameAt(0, tc.id, tc.at); name != tc.expected {
+			t.Errorf("%d at %s: expected %s, got %s", tc.id, tc.at, tc.expected, name)
+		}
+	}
+
+	if !b.IsExistAt(3, date(2012, 1, 1)) {
+		t.Error("expected item 3 exists in 2012")
+	}
+	if b.IsExistAt(3, date(2016, 1, 1)) {
+		t.Error("expected item 3 does not exist in 2016")
+	}
+
+	buf, err := b.JSONAt(date(2012, 1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{`"name":"18%"`, `"name":"10%"`, `"name":"5%"`} {
+		if !strings.Contains(string(buf), s) {
+			t.Errorf("expected %s in %s", s, buf)
+		}
+	}
+
+	buf, err = b.JSONAt(date(2020, 1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(buf), `"name":"18%"`) || strings.Contains(string(buf), `"name":"5%"`) {
+		t.Errorf("unexpected items in %s", buf)
+	}
+}
+
+func TestBook_ValidityFutureVersion(t *testing.T) {
+
+	b := NewConcurrentBook()
+	if err := b.Parse([]byte(`[{"id":1,"name":"20%"}]`)); err != nil {
+		t.Fatal(err)
+	}
+	b.Optimize()
+	h := b.Hash()
+
+	future := time.Now().AddDate(1, 0, 0)
+	err := b.Replace([]Item{
+		{ID: 1, Name: "20%", ValidTo: future},
+		{ID: 1, Name: "22%", ValidFrom: future},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Optimize()
+
+	if b.Hash() == h {
+		t.Error("expected hash is changed by future version")
+	}
+	if name := b.Name(0, 1); name != "20%" {
+		t.Errorf("expected 20%%, got %s", name)
+	}
+	if name := b.NameAt(0, 1, future); name != "22%" {
+		t.Errorf("expected 22%%, got %s", name)
+	}
+
+	// Set renames the current version only.
+	b.Set(1, "21%")
+	if name := b.Name(0, 1); name != "21%" {
+		t.Errorf("expected 21%%, got %s", name)
+	}
+	if name := b.NameAt(0, 1, future); name != "22%" {
+		t.Errorf("expected 22%%, got %s", name)
+	}
+
+	// item without validity interval replaces all versions.
+	if err := b.Replace([]Item{{ID: 1, Name: "25%"}}); err != nil {
+		t.Fatal(err)
+	}
+	if name := b.NameAt(0, 1, future); name != "25%" {
+		t.Errorf("expected 25%%, got %s", name)
+	}
+}
+
+func TestBook_LoadFromSliceValidity(t *testing.T) {
+
+	type rate struct {
+		ID        int
+		Name      string
+		ValidFrom time.Time
+		ValidTo   *time.Time
+	}
+
+	to := date(2019, 1, 1)
+	src := []rate{
+		{ID: 1, Name: "18%", ValidTo: &to},
+		{ID: 1, Name: "20%", ValidFrom: to},
+	}
+
+	b := NewBook()
+	if err := b.LoadFromSlice(src, "ID", "Name", WithValidityAttrs("ValidFrom", "ValidTo")); err != nil {
+		t.Fatal(err)
+	}
+	if name := b.NameAt(0, 1, date(2018, 1, 1)); name != "18%" {
+		t.Errorf("expected 18%%, got %s", name)
+	}
+	if name := b.Name(0, 1); name != "20%" {
+		t.Errorf("expected 20%%, got %s", name)
+	}
+
+	if err := b.LoadFromSlice(src, "ID", "Name", WithValidityAttrs("ValidFrom", "Name")); err == nil {
+		t.Error("expected error for not time attribute")
+	}
+}
+
+func TestFlexBook_Validity(t *testing.T) {
+
+	src := []byte(`[
+		{"id":1,"name":{"en":"Old","ru":"Старый"},"valid_to":"2019-01-01T00:00:00Z"},
+		{"id":1,"name":{"en":"New","ru":"Новый"},"valid_from":"2019-01-01T00:00:00Z"}
+	]`)
+
+	b := NewFlexBook()
+	if err := b.Parse(src); err != nil {
+		t.Fatal(err)
+	}
+
+	ru := ToLangCode("ru")
+	if name := b.Name(ru, 1); name != "Новый" {
+		t.Errorf("expected Новый, got %s", name)
+	}
+	if name := b.NameAt(ru, 1, date(2018, 1, 1)); name != "Старый" {
+		t.Errorf("expected Старый, got %s", name)
+	}
+	if !b.IsExistAt(1, date(2018, 1, 1)) {
+		t.Error("expected item exists in 2018")
+	}
+
+	var buf []byte
+	if err := b.BookAsJSONAt("en", date(2018, 1, 1), &buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(buf), `"name":"Old"`) {
+		t.Errorf("expected Old in %s", buf)
+	}
+}
+
+func TestBook_ParseValidityError(t *testing.T) {
+
+	src := []byte(`[{"id":1,"name":"18%"},{"id":2,"name":"20%","valid_from":"2019-01-01","valid_to":"2018-01-01"}]`)
+
+	if err := NewBook().Parse(src); err == nil || err.Error() != "item 1: valid_to is not after valid_from" {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := NewFlexBook().Parse(src); err == nil || err.Error() != "item 1: valid_to is not after valid_from" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestBook_ValidityOverlap(t *testing.T) {
+
+	tcs := []struct {
+		src string
+		err string
+	}{
+		{`[{"id":1,"name":"18%","valid_to":"2019-06-01"},{"id":1,"name":"20%","valid_from":"2019-01-01"}]`, "item 1: period of id 1 overlaps another period"},
+		{`[{"id":1,"name":"20%","valid_from":"2019-01-01"},{"id":2,"name":"0%"},{"id":1,"name":"22%","valid_from":"2020-01-01"}]`, "item 2: period of id 1 overlaps another period"},
+		{`[{"id":1,"name":"18%","valid_from":"2010-01-01","valid_to":"2020-01-01"},{"id":1,"name":"20%","valid_from":"2015-01-01","valid_to":"2016-01-01"}]`, "item 1: period of id 1 overlaps another period"},
+	}
+
+	for _, tc := range tcs {
+		if err := NewBook().Parse([]byte(tc.src)); err == nil || err.Error() != tc.err {
+			t.Errorf("%s: expected error %q, got %v", tc.src, tc.err, err)
+		}
+		if err := NewFlexBook().Parse([]byte(tc.src)); err == nil || err.Error() != tc.err {
+			t.Errorf("%s: expected error %q, got %v", tc.src, tc.err, err)
+		}
+	}
+
+	// the version valid from the same time is replaced, item without
+	// validity interval replaces all versions.
+	src := []byte(`[
+		{"id":1,"name":"18%","valid_from":"2010-01-01"},
+		{"id":1,"name":"20%","valid_from":"2010-01-01","valid_to":"2019-01-01"},
+		{"id":1,"name":"22%","valid_from":"2019-01-01"},
+		{"id":2,"name":"5%","valid_from":"2019-01-01"},
+		{"id":2,"name":"7%"},
+		{"id":2,"name":"10%","valid_to":"2019-01-01"}
+	]`)
+	if err := NewBook().Parse(src); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBook_ValidityOverlapLoad(t *testing.T) {
+
+	overlapped := []Item{
+		{ID: 1, Name: "18%", ValidTo: date(2019, 6, 1)},
+		{ID: 1, Name: "20%", ValidFrom: date(2019, 1, 1)},
+	}
+	expected := "item 1: period of id 1 overlaps another period"
+
+	if err := NewBook().Replace(overlapped); err == nil || err.Error() != expected {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := NewFlexBook().ReplaceItems(overlapped); err == nil || err.Error() != expected {
+		t.Errorf("unexpected error %v", err)
+	}
+	err := NewFlexBook().Replace([]MultiLangItem{
+		{ID: 1, Name: map[string]string{"en": "18%"}, ValidTo: date(2019, 6, 1)},
+		{ID: 1, Name: map[string]string{"en": "20%"}, ValidFrom: date(2019, 1, 1)},
+	})
+	if err == nil || err.Error() != expected {
+		t.Errorf("unexpected error %v", err)
+	}
+	err = NewBook().Replace([]Item{{ID: 1, Name: "18%", ValidFrom: date(2019, 1, 1), ValidTo: date(2018, 1, 1)}})
+	if err == nil || err.Error() != "item 0: valid_to is not after valid_from" {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	type rate struct {
+		ID        int
+		Name      string
+		ValidFrom time.Time
+		ValidTo   time.Time
+	}
+	src := []rate{{ID: 1, Name: "18%", ValidTo: date(2019, 6, 1)}, {ID: 1, Name: "20%", ValidFrom: date(2019, 1, 1)}}
+	if err := NewBook().LoadFromSlice(src, "ID", "Name", WithValidityAttrs("ValidFrom", "ValidTo")); err == nil || err.Error() != expected {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := NewFlexBook().LoadFromSlice(src, "ID", "Name", WithValidityAttrs("ValidFrom", "ValidTo")); err == nil || err.Error() != expected {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	// versions added to the book are checked against existing ones.
+	b := NewFlexBook()
+	if err := b.Parse([]byte(`[{"id":1,"name":"20%","valid_from":"2019-01-01"}]`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Parse([]byte(`[{"id":1,"name":"18%","valid_to":"2019-06-01"}]`)); err == nil || err.Error() != "item 0: period of id 1 overlaps another period" {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := b.Parse([]byte(`[{"id":1,"name":"18%","valid_to":"2019-01-01"}]`)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBook_ValidityHashLocation(t *testing.T) {
+
+	now := time.Now().In(time.FixedZone("UTC+3", 3*60*60))
+	hash := func(from time.Time) uint64 {
+		b := NewBook()
+		if err := b.Replace([]Item{{ID: 1, Name: "A", ValidFrom: from}, {ID: 2, Name: "B", ValidTo: from}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.Optimize(); err != nil {
+			t.Fatal(err)
+		}
+		return b.Hash()
+	}
+
+	if hash(now) != hash(now.UTC()) {
+		t.Error("hash depends on location")
+	}
+	if hash(now) == hash(now.Add(time.Second)) {
+		t.Error("hash does not depend on validity")
+	}
+}
@@ -0,0 +1,178 @@
+package refbook
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// JSONOption holds layout of JSON written by WriteJSON.
+type JSONOption struct {
+	itemsField string
+	hashField  string
+	idField    string
+	nameField  string
+}
+
+func newJSONOption(f []func(*JSONOption)) JSONOption {
+	o := JSONOption{itemsField: "items", hashField: "hash", idField: "id", nameField: "name"}
+	for i := range f {
+		f[i](&o)
+	}
+	return o
+}
+
+func (o *JSONOption) isDefault() bool {
+	return *o == newJSONOption(nil)
+}
+
+// WithEnvelope replaces names of fields "items" and "hash" of JSON object.
+// For instance WithEnvelope("data", "hash") gives {"data":[...],"hash":"..."}.
+func WithEnvelope(items, hash string) func(o *JSONOption) {
+	return func(o *JSONOption) {
+		o.itemsField = items
+		o.hashField = hash
+	}
+}
+
+// WithRowFields replaces names of fields "id" and "name" of items.
+// It's ignored by RecordBook, records are written as they are.
+func WithRowFields(id, name string) func(o *JSONOption) {
+	return func(o *JSONOption) {
+		o.idField = id
+		o.nameField = name
+	}
+}
+
+// WriteJSON writes items to w as JSON object {"items":[...],"hash":"..."}
+// with field names set by options. Optimized book in default layout is
+// written from JSON compiled by Optimize, otherwise items are encoded one
+// by one without building of the whole JSON in memory.
+func (b *BookOf[K, V]) WriteJSON(w io.Writer, f ...func(*JSONOption)) (int64, error) {
+	o := newJSONOption(f)
+
+	s := b.compiled()
+	if o.isDefault() && !s.isCompileRequired {
+		n, err := w.Write(s.jsonCompiled)
+		return int64(n), err
+	}
+	return b.writeJSON(w, s, &o)
+}
+
+// WriteJSON writes items in language lang to w, see Book.WriteJSON.
+func (b *FlexBook) WriteJSON(w io.Writer, lang string, f ...func(*JSONOption)) (int64, error) {
+	fb := b.compiled()
+	return fb.book[fb.languageIndex(lang, &b.langs)].WriteJSON(w, f...)
+}
+
+func (b *BookOf[K, V]) writeJSON(w io.Writer, s *bookSnapshot[K, V], o *JSONOption) (int64, error) {
+
+	h := s.jsonInput.Hash
+	if s.isCompileRequired {
+		var err error
+		if h, err = hashItems(s.jsonInput.Items, s.periods); err != nil {
+			return 0, err
+		}
+	}
+
+	jw := jsonWriter{w: bufio.NewWriter(w)}
+	jw.writeString("{")
+	jw.writeValue(o.itemsField)
+	jw.writeString(":[")
+
+	items := b.jsonItems(s.jsonInput.Items)
+	for i := range items {
+		if i > 0 {
+			jw.writeString(",")
+		}
+		if b.rowOf != nil {
+			jw.writeValue(b.rowOf(items[i]))
+			continue
+		}
+		jw.writeValue(jsonRow[K, V]{&items[i], o})
+	}
+
+	jw.writeString("],")
+	jw.writeValue(o.hashField)
+	jw.writeString(`:"` + strconv.FormatUint(h, 10) + `"}`)
+
+	if jw.err == nil {
+		jw.err = jw.w.Flush()
+	}
+	return jw.n, jw.err
+}
+
+// jsonRow is the item written by ItemOf.MarshalJSON with fields "id" and
+// "name" renamed by WithRowFields.
+type jsonRow[K comparable, V any] struct {
+	item *ItemOf[K, V]
+	o    *JSONOption
+}
+
+func (r jsonRow[K, V]) MarshalJSON() ([]byte, error) {
+	if r.o.idField == "id" && r.o.nameField == "name" {
+		return r.item.MarshalJSON()
+	}
+
+	res, err := appendField([]byte{'{'}, r.o.idField, r.item.ID)
+	if err != nil {
+		return nil, err
+	}
+	if res, err = appendField(append(res, ','), r.o.nameField, r.item.Name); err != nil {
+		return nil, err
+	}
+
+	// other fields follow as ItemOf.MarshalJSON writes them.
+	attrs, err := json.Marshal(r.item.attrs())
+	if err != nil {
+		return nil, err
+	}
+	if len(attrs) > 2 {
+		res = append(append(res, ','), attrs[1:len(attrs)-1]...)
+	}
+	return append(res, '}'), nil
+}
+
+// appendField appends JSON field "key":value to buf.
+func appendField(buf []byte, key string, value interface{}) ([]byte, error) {
+	k, err := json.Marshal(key)
+	if err != nil {
+		return nil, err
+	}
+	v, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return append(append(append(buf, k...), ':'), v...), nil
+}
+
+// jsonWriter writes JSON by parts. It does nothing after the first error.
+type jsonWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (jw *jsonWriter) writeString(s string) {
+	if jw.err != nil {
+		return
+	}
+	n, err := jw.w.WriteString(s)
+	jw.n += int64(n)
+	jw.err = err
+}
+
+func (jw *jsonWriter) writeValue(v interface{}) {
+	if jw.err != nil {
+		return
+	}
+	buf, err := json.Marshal(v)
+	if err != nil {
+		jw.err = err
+		return
+	}
+	n, err := jw.w.Write(buf)
+	jw.n += int64(n)
+	jw.err = err
+}
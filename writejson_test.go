@@ -0,0 +1,125 @@
+package refbook
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestBook_WriteJSON(t *testing.T) {
+
+	b := NewConcurrentBook()
+	if err := b.Parse([]byte(`[{"id":1,"name":"A"},{"id":2,"name":"<B>"}]`)); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	n, err := b.WriteJSON(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != string(b.JSON()) || n != int64(buf.Len()) {
+		t.Errorf("expected %s, got %s (%d)", b.JSON(), buf.String(), n)
+	}
+
+	// streamed default layout is the same as compiled.
+	buf.Reset()
+	if _, err := b.writeJSON(&buf, b.snapshot(), &JSONOption{"items", "hash", "id", "name"}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != string(b.JSON()) {
+		t.Errorf("expected %s, got %s", b.JSON(), buf.String())
+	}
+
+	buf.Reset()
+	if _, err := b.WriteJSON(&buf, WithEnvelope("data", "hash"), WithRowFields("ID", "Name")); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"data":[{"ID":1,"Name":"A"},{"ID":2,"Name":"\u003cB\u003e"}],"hash":"` + strconv.FormatUint(b.Hash(), 10) + `"}`
+	if buf.String() != expected {
+		t.Errorf("expected %s, got %s", expected, buf.String())
+	}
+
+	// not optimized book is streamed with actual hash.
+	b.Set(3, "C")
+	buf.Reset()
+	if _, err := b.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Optimize(); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != string(b.JSON()) {
+		t.Errorf("expected %s, got %s", b.JSON(), buf.String())
+	}
+
+	if _, err := b.WriteJSON(failWriter{}, WithEnvelope("data", "hash")); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestBook_WriteJSONRowFields(t *testing.T) {
+
+	b := NewBook(WithInactive())
+	src := `[{"id":1,"name":"A","aliases":["AA"],"sort_order":2,"valid_from":"2019-01-01T00:00:00Z"},` +
+		`{"id":2,"name":"B","is_active":false}]`
+	if err := b.Parse([]byte(src)); err != nil {
+		t.Fatal(err)
+	}
+
+	// renamed rows keep all fields written by ItemOf.MarshalJSON.
+	var buf bytes.Buffer
+	if _, err := b.WriteJSON(&buf, WithRowFields("code", "title")); err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.NewReplacer(`"id"`, `"code"`, `"name"`, `"title"`).Replace(string(b.JSON()))
+	if buf.String() != expected {
+		t.Errorf("expected %s, got %s", expected, buf.String())
+	}
+}
+
+func TestRecordBook_WriteJSON(t *testing.T) {
+
+	b := NewRecordBook[int, struct {
+		ID   int    `json:"id"`
+		Code string `json:"code"`
+	}]("ID", "Code")
+	if err := b.Parse([]byte(`[{"id":1,"code":"A"}]`)); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := b.WriteJSON(&buf, WithEnvelope("data", "hash"), WithRowFields("x", "y")); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"data":[{"id":1,"code":"A"}],"hash":"` + strconv.FormatUint(b.Hash(), 10) + `"}`
+	if buf.String() != expected {
+		t.Errorf("expected %s, got %s", expected, buf.String())
+	}
+}
+
+func TestFlexBook_WriteJSON(t *testing.T) {
+
+	b := NewFlexBook(WithDefaultLang("en"))
+	b.AddMultiLangItem(MultiLangItem{ID: 1, Name: map[string]string{"en": "A", "ru": "АА"}})
+	if err := b.Optimize(); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := b.WriteJSON(&buf, "ru", WithEnvelope("data", "hash")); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"data":[{"id":1,"name":"АА"}],"hash":"` + strconv.FormatUint(b.Hash("ru"), 10) + `"}`
+	if buf.String() != expected {
+		t.Errorf("expected %s, got %s", expected, buf.String())
+	}
+}
+
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}